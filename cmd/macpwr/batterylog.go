@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/born1337/macpwr/internal/battery"
+	"github.com/born1337/macpwr/internal/batterylog"
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/powerlog"
+	"github.com/born1337/macpwr/internal/runner"
+
+	"github.com/spf13/cobra"
+)
+
+// sparkWidth is the most columns a history sparkline uses
+const sparkWidth = 48
+
+func batteryLogCmd() *cobra.Command {
+	var interval time.Duration
+	var once, install, uninstall bool
+
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Record battery samples to the battery log",
+		Long: `Record the battery's charge, capacity, cycle count, temperature and
+power draw to ~/.config/macpwr/battery.jsonl.
+
+Runs in the foreground until stopped, or installs a launchd agent that takes
+a sample every interval, including while macpwr is not running.
+
+Examples:
+  macpwr battery log                Record every 5 minutes until Ctrl+C
+  macpwr battery log --once         Record a single sample
+  macpwr battery log --install      Record in the background with launchd
+  macpwr battery log --uninstall    Remove the launchd agent`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// launchd counts StartInterval in whole seconds
+			if install && interval < time.Second {
+				display.Error("--interval must be at least 1s for the launchd agent")
+				return
+			}
+			if interval <= 0 {
+				display.Error("--interval must be greater than 0")
+				return
+			}
+
+			switch {
+			case install:
+				installBatteryAgent(interval)
+				return
+			case uninstall:
+				uninstallBatteryAgent()
+				return
+			case once:
+				recordSample()
+				return
+			}
+
+			fmt.Printf("\n%sRecording battery samples every %s to %s%s\n", display.Bold, interval, batterylog.Path(), display.Reset)
+			fmt.Printf("%sPress Ctrl+C to stop%s\n\n", display.Dim, display.Reset)
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				recordSample()
+				select {
+				case <-sigChan:
+					fmt.Println("\nRecording stopped.")
+					return
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+
+	cmd.Flags().DurationVarP(&interval, "interval", "i", 5*time.Minute, "Time between samples")
+	cmd.Flags().BoolVar(&once, "once", false, "Record one sample and exit")
+	cmd.Flags().BoolVar(&install, "install", false, "Install a launchd agent that records in the background")
+	cmd.Flags().BoolVar(&uninstall, "uninstall", false, "Remove the launchd agent")
+
+	return cmd
+}
+
+// recordSample appends one sample to the battery log and prints it
+func recordSample() {
+	info, err := battery.GetInfo()
+	if err != nil {
+		display.Error("Failed to read battery info: " + err.Error())
+		return
+	}
+	if info == nil {
+		display.Error("No battery found (desktop Mac?)")
+		return
+	}
+
+	s := batterylog.FromInfo(info, time.Now())
+	if err := batterylog.Append(s); err != nil {
+		display.Error("Failed to write battery log: " + err.Error())
+		return
+	}
+	fmt.Printf("%s  %4d%%  %d/%d mAh  %d cycles  %.1f°C  %s\n",
+		s.Time.Format("15:04:05"), s.Charge, s.Capacity, s.MaxCapacity,
+		s.CycleCount, s.Temperature, batteryRate(s.Watts))
+}
+
+func installBatteryAgent(interval time.Duration) {
+	exe, err := os.Executable()
+	if err != nil {
+		display.Error("Cannot find the macpwr executable: " + err.Error())
+		return
+	}
+
+	path := batterylog.AgentPath()
+	if dryRun {
+		printAgentDryRun("Write", path, "launchctl", "load", "-w", path)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		display.Error("Failed to create " + filepath.Dir(path) + ": " + err.Error())
+		return
+	}
+	if err := os.WriteFile(path, []byte(batterylog.AgentPlist(exe, interval)), 0644); err != nil {
+		display.Error("Failed to write launchd agent: " + err.Error())
+		return
+	}
+	if err := runner.Default.Run("launchctl", "load", "-w", path); err != nil {
+		display.Error("Failed to load launchd agent: " + err.Error())
+		return
+	}
+	display.Success(fmt.Sprintf("Recording a battery sample every %s (agent %s)", interval, path))
+}
+
+func uninstallBatteryAgent() {
+	path := batterylog.AgentPath()
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		display.Info("The battery log agent is not installed")
+		return
+	}
+	if dryRun {
+		printAgentDryRun("Remove", path, "launchctl", "unload", "-w", path)
+		return
+	}
+	if err := runner.Default.Run("launchctl", "unload", "-w", path); err != nil {
+		display.Warning("Failed to unload launchd agent: " + err.Error())
+	}
+	if err := os.Remove(path); err != nil {
+		display.Error("Failed to remove launchd agent: " + err.Error())
+		return
+	}
+	display.Success("Removed the battery log agent; recorded samples were kept")
+}
+
+// printAgentDryRun shows the agent file and launchctl command that
+// --install or --uninstall would touch under --dry-run
+func printAgentDryRun(action, path string, argv ...string) {
+	fmt.Printf("%sDry run: no changes will be made%s\n", display.Yellow, display.Reset)
+	display.Section("Agent")
+	fmt.Printf("  %s %s\n", action, path)
+	display.Section("Commands")
+	fmt.Printf("  %s\n\n", strings.Join(argv, " "))
+}
+
+func batteryHistoryCmd() *cobra.Command {
+	var since, until string
+	var asCSV, asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show charge and health trends from the battery log",
+		Long: `Show the charge, health, power and temperature recorded by
+'macpwr battery log' as sparklines and a daily summary, or export the
+samples as CSV or JSON.
+
+Examples:
+  macpwr battery history                  The last 7 days
+  macpwr battery history --since 30d      The last 30 days
+  macpwr battery history --csv > bat.csv  Export samples as CSV`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			filter, err := logFilter(since, until, nil)
+			if err != nil {
+				display.Error(err.Error())
+				return
+			}
+			if filter.Until.IsZero() {
+				filter.Until = time.Now()
+			}
+
+			all, err := batterylog.List()
+			if err != nil {
+				display.Error("Failed to read battery log: " + err.Error())
+				return
+			}
+			samples := batterylog.Between(all, filter.Since, filter.Until)
+
+			switch {
+			case asCSV:
+				if err := batterylog.WriteCSV(os.Stdout, samples); err != nil {
+					display.Error("Failed to write CSV: " + err.Error())
+				}
+				return
+			case asJSON:
+				if samples == nil {
+					samples = []batterylog.Sample{}
+				}
+				data, _ := json.MarshalIndent(samples, "", "  ")
+				fmt.Println(string(data))
+				return
+			}
+
+			printBatteryHistory(samples, filter)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "7d", "Show samples after this time")
+	cmd.Flags().StringVar(&until, "until", "", "Show samples before this time")
+	cmd.Flags().BoolVar(&asCSV, "csv", false, "Export samples as CSV")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Export samples as JSON")
+
+	return cmd
+}
+
+func printBatteryHistory(samples []batterylog.Sample, filter powerlog.Filter) {
+	fmt.Printf("\n%sBattery History%s  %s%s to %s, %d samples%s\n",
+		display.Bold, display.Reset, display.Dim,
+		filter.Since.Format("2006-01-02 15:04"), filter.Until.Format("2006-01-02 15:04"),
+		len(samples), display.Reset)
+
+	if len(samples) == 0 {
+		fmt.Printf("\n  %sNo battery samples recorded. Run 'macpwr battery log' to start recording.%s\n\n",
+			display.Dim, display.Reset)
+		return
+	}
+
+	// Fewer columns than samples keeps sparse logs from showing gaps
+	width := min(sparkWidth, len(samples))
+	display.Section("Trends")
+	series := []struct {
+		label  string
+		value  func(batterylog.Sample) float64
+		format func(float64) string
+	}{
+		{"Charge", func(s batterylog.Sample) float64 { return float64(s.Charge) },
+			func(v float64) string { return fmt.Sprintf("%.0f%%", v) }},
+		{"Health", func(s batterylog.Sample) float64 { return float64(s.Health()) },
+			func(v float64) string { return fmt.Sprintf("%.0f%%", v) }},
+		{"Power", func(s batterylog.Sample) float64 { return s.Watts },
+			display.FormatWatts},
+		{"Temperature", func(s batterylog.Sample) float64 { return s.Temperature },
+			func(v float64) string { return fmt.Sprintf("%.0f°C", v) }},
+	}
+	for _, sr := range series {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, s := range samples {
+			lo, hi = math.Min(lo, sr.value(s)), math.Max(hi, sr.value(s))
+		}
+		scaleLo, scaleHi := lo, hi
+		if sr.label == "Charge" {
+			scaleLo, scaleHi = 0, 100
+		}
+		points := batterylog.Resample(samples, filter.Since, filter.Until, width, sr.value)
+		fmt.Printf("  %-12s %s%s%s  %s%s – %s%s\n", sr.label,
+			display.Cyan, display.Sparkline(points, scaleLo, scaleHi), display.Reset,
+			display.Dim, sr.format(lo), sr.format(hi), display.Reset)
+	}
+
+	display.Section("Daily")
+	fmt.Printf("  %s%-10s  %7s  %-9s  %6s  %6s  %5s  %9s%s\n", display.Dim,
+		"Date", "Samples", "Charge", "Health", "Cycles", "Temp", "Avg Power", display.Reset)
+	for _, d := range batterylog.Days(samples) {
+		fmt.Printf("  %-10s  %7d  %-9s  %6s  %6d  %5s  %9s\n",
+			d.Date.Format("2006-01-02"), d.Samples,
+			fmt.Sprintf("%d–%d%%", d.MinCharge, d.MaxCharge),
+			fmt.Sprintf("%d%%", d.Health), d.CycleCount,
+			fmt.Sprintf("%.0f°C", d.MaxTemp), display.FormatWatts(d.AvgWatts))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/born1337/macpwr/internal/battery"
+	"github.com/born1337/macpwr/internal/display"
+
+	"github.com/spf13/cobra"
+)
+
+func chargerCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "charger",
+		Aliases: []string{"adapter"},
+		Short:   "Show power adapter and charging details",
+		Long: `Show the connected power adapter: its name, rated watts, the USB Power
+Delivery voltage and current it negotiated, family code and serial number,
+and whether it supplies enough power for what the Mac is drawing.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			info, err := battery.GetInfo()
+			if err != nil {
+				display.Error("Failed to read adapter info: " + err.Error())
+				return
+			}
+			if info == nil {
+				display.Error("No adapter information (ioreg reports no AppleSmartBattery)")
+				return
+			}
+
+			display.Header("Power Adapter")
+
+			a := info.AdapterDetails
+			if !a.Connected() {
+				fmt.Printf("\n  %sNo power adapter connected%s\n\n", display.Dim, display.Reset)
+				return
+			}
+
+			display.Section("Adapter")
+			display.KV("Name", orDash(a.Name))
+			if a.Manufacturer != "" {
+				display.KV("Manufacturer", a.Manufacturer)
+			}
+			if a.Description != "" {
+				display.KV("Type", a.Description)
+			}
+			display.KV("Rated", fmt.Sprintf("%d W", a.Watts))
+			if a.AdapterVoltage > 0 && a.Current > 0 {
+				display.KV("Negotiated", fmt.Sprintf("%s (%s)",
+					formatVoltsAmps(a.AdapterVoltage, a.Current), display.FormatWatts(a.InputWatts())))
+			}
+			display.KV("Family Code", orDash(a.Family()))
+			display.KV("Serial", orDash(a.SerialString))
+			if a.IsWireless {
+				display.KV("Wireless", display.FormatBool(true))
+			}
+
+			if len(a.UsbHvcMenu) > 0 {
+				display.Section("USB-PD Options")
+				for _, opt := range a.UsbHvcMenu {
+					marker, style := " ", display.Dim
+					if opt.Index == a.UsbHvcHvcIndex {
+						marker, style = "▸", display.Bold
+					}
+					fmt.Printf("  %s%s %15s %9s%s\n", style, marker,
+						formatVoltsAmps(opt.MaxVoltage, opt.MaxCurrent), display.FormatWatts(opt.Watts()), display.Reset)
+				}
+			}
+
+			c := info.ChargerData
+			display.Section("Charging")
+			switch {
+			case info.FullyCharged:
+				display.KV("Status", display.Green+"Fully Charged"+display.Reset)
+			case c.NotChargingReason != 0:
+				display.KV("Status", fmt.Sprintf("%sNot Charging%s %s(reason %#x)%s",
+					display.Yellow, display.Reset, display.Dim, c.NotChargingReason, display.Reset))
+			case info.IsCharging:
+				display.KV("Status", display.Yellow+"Charging"+display.Reset)
+			default:
+				display.KV("Status", "Not Charging")
+			}
+			if c.ChargingCurrent > 0 {
+				display.KV("Charging Current", fmt.Sprintf("%d mA", c.ChargingCurrent))
+			}
+			if c.ChargingVoltage > 0 {
+				display.KV("Charging Voltage", fmt.Sprintf("%d mV", c.ChargingVoltage))
+			}
+
+			d := info.Draw()
+			display.Section("Load")
+			if d.System > 0 {
+				display.KV("System Draw", display.FormatWatts(d.System))
+			} else {
+				display.KV("System Draw", "—")
+			}
+			display.KV("Adapter Input", display.FormatWatts(d.Adapter))
+			display.KV("Battery", batteryRate(d.Battery))
+			if info.Underpowered() {
+				display.KV("Adapter", display.Red+"Underpowered"+display.Reset)
+				display.Warning("The Mac is drawing more than this adapter supplies; use a higher-wattage charger")
+			} else if d.System > 0 {
+				// Power going into the battery also comes from the adapter
+				headroom := d.Adapter - d.System - max(d.Battery, 0)
+				display.KV("Adapter", fmt.Sprintf("%sSufficient%s %s(%s headroom)%s",
+					display.Green, display.Reset, display.Dim, display.FormatWatts(headroom), display.Reset))
+			}
+
+			fmt.Println()
+		},
+	}
+}
+
+// formatVoltsAmps formats a millivolt and milliamp pair, such as "20.0 V × 3.35 A"
+func formatVoltsAmps(mV, mA int) string {
+	return fmt.Sprintf("%.1f V × %.2f A", float64(mV)/1000, float64(mA)/1000)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/presets"
+	"github.com/born1337/macpwr/internal/profiles"
+	"github.com/born1337/macpwr/internal/settings"
+
+	"github.com/spf13/cobra"
+)
+
+// target is a desired configuration that can be planned against current settings
+type target interface {
+	Plan(current *settings.AllSettings) *plan.Plan
+}
+
+// resolveTarget finds a preset, saved profile or profile file by name
+func resolveTarget(name string) (target, string, error) {
+	if strings.ContainsRune(name, os.PathSeparator) || strings.HasSuffix(name, ".profile") {
+		p, err := profiles.LoadFile(name)
+		if err != nil {
+			return nil, "", err
+		}
+		return p, "file " + name, nil
+	}
+	if p := presets.Get(name); p != nil {
+		return p, "preset " + p.Name, nil
+	}
+	if profiles.Exists(name) {
+		p, err := profiles.Load(name)
+		if err != nil {
+			return nil, "", err
+		}
+		return p, "profile " + name, nil
+	}
+	return nil, "", fmt.Errorf("no preset, profile or file named %q", name)
+}
+
+// diffEntry is the machine-readable form of a changed setting
+type diffEntry struct {
+	Source  settings.Source `json:"source"`
+	Key     string          `json:"key"`
+	Current *int            `json:"current"`
+	Target  int             `json:"target"`
+}
+
+func diffCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "diff [preset|profile|file]",
+		Short: "Compare current settings with a preset, profile or snapshot",
+		Long: `Compare current settings with a preset, saved profile or profile file.
+
+Only settings that differ are listed, per power source.
+
+Examples:
+  macpwr diff battery-saver       Compare with a built-in preset
+  macpwr diff work                Compare with a saved profile
+  macpwr diff ./laptop.profile    Compare with a profile file
+  macpwr diff work --json         Machine-readable output`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			t, desc, err := resolveTarget(args[0])
+			if err != nil {
+				display.Error(err.Error())
+				return
+			}
+
+			current, err := settings.Get()
+			if err != nil {
+				display.Error("Failed to read power settings: " + err.Error())
+				return
+			}
+
+			var changes []plan.Change
+			for _, c := range t.Plan(current).Changes() {
+				if c.Changed() {
+					changes = append(changes, c)
+				}
+			}
+
+			if asJSON {
+				printDiffJSON(changes)
+				return
+			}
+
+			display.Header("Settings Diff: " + desc)
+			if len(changes) == 0 {
+				display.Success("Current settings match " + desc)
+				fmt.Println()
+				return
+			}
+
+			display.TableHeader("Current", "Target")
+			var last settings.Source
+			for _, c := range changes {
+				if c.Source != last {
+					if last != "" {
+						display.TableSep()
+					}
+					display.TableGroup(c.Source.Label())
+					last = c.Source
+				}
+
+				label, before, after := c.Key, "?", fmt.Sprint(c.After)
+				if k, ok := settings.LookupKey(c.Key); ok {
+					label = k.Label
+					after = formatValue(k, c.After)
+					if c.Known {
+						before = formatValue(k, c.Before)
+					}
+				} else if c.Known {
+					before = fmt.Sprint(c.Before)
+				}
+				display.TableRow(label, display.Red+before+display.Reset, display.Green+after+display.Reset)
+			}
+			display.TableFooter()
+			fmt.Printf("\n%d setting(s) differ\n\n", len(changes))
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output differences as JSON")
+
+	return cmd
+}
+
+func printDiffJSON(changes []plan.Change) {
+	entries := []diffEntry{}
+	for _, c := range changes {
+		e := diffEntry{Source: c.Source, Key: c.Key, Target: c.After}
+		if c.Known {
+			before := c.Before
+			e.Current = &before
+		}
+		entries = append(entries, e)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(entries)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/enforce"
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/settings"
+
+	"github.com/spf13/cobra"
+)
+
+// errDrift makes macpwr exit non-zero when enforce --check finds drift
+var errDrift = errors.New("settings have drifted from the pinned profile")
+
+func enforceCmd() *cobra.Command {
+	var interval time.Duration
+	var check, fix bool
+
+	cmd := &cobra.Command{
+		Use:   "enforce [profile]",
+		Short: "Watch for settings drifting from a pinned profile",
+		Long: `Periodically compare current settings with a pinned profile and log
+every drift to ~/.config/macpwr/drift.log.
+
+Examples:
+  macpwr enforce work             Report drift every 5 minutes
+  macpwr enforce work --fix       Reapply the profile when drift is found
+  macpwr enforce work -i 1m       Check every minute
+  macpwr enforce work --check     Check once; exit 1 on drift (for scripts)`,
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				err := errors.New("--interval must be greater than 0")
+				display.Error(err.Error())
+				return err
+			}
+
+			t, desc, err := resolveTarget(args[0])
+			if err != nil {
+				display.Error(err.Error())
+				return err
+			}
+
+			// Keys the Mac doesn't report can never match; say so once
+			// rather than treating them as drift on every check
+			if current, err := settings.Get(); err == nil {
+				for _, c := range enforce.Unreported(t.Plan(current)) {
+					display.Warning(fmt.Sprintf("%s %s is not reported by this Mac and is not enforced", c.Source.Label(), c.Key))
+				}
+			}
+
+			if check {
+				drifted, err := enforceOnce(t, args[0], fix, commandLine(cmd, args))
+				if err != nil {
+					return err
+				}
+				if drifted {
+					return errDrift
+				}
+				display.Success("Settings match " + desc)
+				return nil
+			}
+
+			fmt.Printf("\n%sEnforcing %s every %s%s\n", display.Bold, desc, interval, display.Reset)
+			fmt.Printf("%sPress Ctrl+C to stop%s\n\n", display.Dim, display.Reset)
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				if _, err := enforceOnce(t, args[0], fix, commandLine(cmd, args)); err != nil {
+					display.Error(err.Error())
+				}
+				select {
+				case <-sigChan:
+					fmt.Println("\nEnforcement stopped.")
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+
+	cmd.Flags().DurationVarP(&interval, "interval", "i", 5*time.Minute, "Time between checks")
+	cmd.Flags().BoolVar(&check, "check", false, "Check once and exit non-zero on drift")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Reapply the profile when drift is found")
+
+	return cmd
+}
+
+// enforceOnce compares current settings with the pinned target, logs any
+// drift and optionally reapplies the target. It reports whether drift was found.
+func enforceOnce(t target, pinned string, fix bool, command string) (bool, error) {
+	current, err := settings.Get()
+	if err != nil {
+		return false, fmt.Errorf("failed to read power settings: %w", err)
+	}
+
+	drift := enforce.Drift(t.Plan(current))
+	if len(drift) == 0 {
+		return false, nil
+	}
+
+	// Reapply only what drifted, leaving out keys the Mac doesn't report
+	pl := plan.New(current)
+	stamp := time.Now().Format("15:04:05")
+	for _, c := range drift {
+		display.Warning(fmt.Sprintf("%s %s %s is %d, pinned %d", stamp, c.Source.Label(), c.Key, c.Before, c.After))
+		pl.Set(c.Source, c.Key, c.After)
+	}
+
+	fixed := fix && applyPlan(pl, command, "Failed to reapply: ")
+	if fixed {
+		display.Success("Reapplied " + pinned)
+	}
+	if err := enforce.Log(pinned, drift, fixed); err != nil {
+		display.Warning("Could not write drift log: " + err.Error())
+	}
+	return true, nil
+}
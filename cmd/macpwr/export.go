@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/export"
+	"github.com/born1337/macpwr/internal/settings"
+
+	"github.com/spf13/cobra"
+)
+
+func exportCmd() *cobra.Command {
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export [preset|profile|file]",
+		Short: "Export settings as a pmset shell script",
+		Long: `Export the current settings, or a preset, saved profile or profile file,
+as a shell script of pmset commands. The script only changes settings that
+differ, so it can be kept in dotfiles and replayed on any Mac without macpwr.
+
+Examples:
+  macpwr export --format sh                  Print the current settings
+  macpwr export work -o power.sh             Write a profile to power.sh
+  macpwr export battery-saver                Print a built-in preset`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if !slices.Contains(export.Formats, format) {
+				display.Error(fmt.Sprintf("Unknown format %q (use %s)", format, strings.Join(export.Formats, ", ")))
+				return
+			}
+
+			current, err := settings.Get()
+			if err != nil {
+				display.Error("Failed to read power settings: " + err.Error())
+				return
+			}
+
+			title := "the current settings"
+			targets := export.Targets(current)
+			if len(args) == 1 {
+				t, desc, err := resolveTarget(args[0])
+				if err != nil {
+					display.Error(err.Error())
+					return
+				}
+				title, targets = desc, t.Plan(current).Targets
+			}
+			if current.Caps != nil && current.Caps.Model != "" {
+				title += " on a " + current.Caps.Model
+			}
+
+			var buf bytes.Buffer
+			if err := export.Shell(&buf, title, targets, describeValue); err != nil {
+				display.Error("Failed to export settings: " + err.Error())
+				return
+			}
+
+			if output == "" {
+				fmt.Print(buf.String())
+				return
+			}
+			if err := os.WriteFile(output, buf.Bytes(), 0755); err != nil {
+				display.Error("Failed to write script: " + err.Error())
+				return
+			}
+			display.Success(fmt.Sprintf("Exported %s to %s", title, output))
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "sh", "Output format (sh)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the script to a file instead of stdout")
+
+	return cmd
+}
+
+// describeValue returns the plain-text comment for an exported value
+func describeValue(key string, val int) string {
+	k, ok := settings.LookupKey(key)
+	if !ok {
+		return ""
+	}
+	return k.Label + ": " + display.Plain(formatValue(k, val))
+}
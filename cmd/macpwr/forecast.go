@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/born1337/macpwr/internal/battery"
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/forecast"
+
+	"github.com/spf13/cobra"
+)
+
+func batteryForecastCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "forecast",
+		Short: "Estimate when battery health reaches 80%",
+		Long: `Record the battery's full charge capacity and cycle count, fit the trend
+of every reading recorded so far and estimate the date and cycle count at
+which health falls to 80%, the level at which Apple recommends service.
+
+Readings are kept in ~/.config/macpwr/battery-health.jsonl; run forecast
+every few weeks and the estimate improves as readings accumulate.
+
+Examples:
+  macpwr battery forecast          Record a reading and show the estimate
+  macpwr battery forecast --json   Machine-readable output for scripts`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			info, err := battery.GetInfo()
+			if err != nil {
+				display.Error("Failed to read battery info: " + err.Error())
+				return
+			}
+			if info == nil {
+				display.Error("No battery found (desktop Mac?)")
+				return
+			}
+
+			now := time.Now()
+			if _, err := forecast.Record(forecast.FromInfo(info, now)); err != nil {
+				display.Warning("Failed to record battery health: " + err.Error())
+			}
+			readings, err := forecast.List()
+			if err != nil {
+				display.Error("Failed to read battery health record: " + err.Error())
+				return
+			}
+			if len(readings) == 0 {
+				readings = []forecast.Reading{forecast.FromInfo(info, now)}
+			}
+
+			made, _ := info.Manufactured()
+			e := forecast.Project(readings, made)
+
+			if asJSON {
+				data, _ := json.MarshalIndent(e, "", "  ")
+				fmt.Println(string(data))
+				return
+			}
+			printForecast(e, info, now)
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+
+	return cmd
+}
+
+func printForecast(e forecast.Estimate, info *battery.Info, now time.Time) {
+	display.Header("Battery Forecast")
+
+	display.Section("Health")
+	display.KV("Current", fmt.Sprintf("%s %s(%d of %d mAh design)%s",
+		formatHealth(e.Health), display.Dim, info.ActualMaxCapacity(), info.DesignCapacity, display.Reset))
+	display.KV("Cycle Count", fmt.Sprint(e.CycleCount))
+	display.KV("Readings", fmt.Sprintf("%d since %s", e.Readings, e.First.Format("2006-01-02")))
+
+	display.Section("Trend")
+	display.KV("Per Year", formatHealthChange(e.PerYear))
+	display.KV("Per 100 Cycles", formatHealthChange(e.Per100Cycles))
+
+	display.Section(fmt.Sprintf("Service Threshold (%.0f%%)", forecast.Threshold))
+	if e.Reached {
+		display.KV("Status", display.Red+"Reached"+display.Reset)
+		display.Warning("Battery health is below 80%; Apple recommends service")
+	} else {
+		if e.Date != nil {
+			display.KV("Estimated Date", fmt.Sprintf("%s %s(%s)%s",
+				e.Date.Format("Jan 2006"), display.Dim, untilDate(now, *e.Date), display.Reset))
+		} else {
+			display.KV("Estimated Date", noEstimate(e.PerYear, e.DateReason))
+		}
+		if e.Cycles != nil {
+			display.KV("Estimated Cycles", fmt.Sprintf("%d %s(%d more)%s",
+				*e.Cycles, display.Dim, *e.Cycles-e.CycleCount, display.Reset))
+		} else {
+			display.KV("Estimated Cycles", noEstimate(e.Per100Cycles, e.CyclesReason))
+		}
+	}
+
+	if e.AssumesNew {
+		fmt.Printf("\n  %sToo few readings for a trend yet, so 100%% health when new is assumed.\n  Run 'macpwr battery forecast' again over the coming weeks.%s\n",
+			display.Dim, display.Reset)
+	}
+	fmt.Println()
+}
+
+// formatHealth formats a health percentage, in red below forecast.Threshold
+func formatHealth(h float64) string {
+	color := display.Green
+	if h < forecast.Threshold {
+		color = display.Red
+	}
+	return fmt.Sprintf("%s%.1f%%%s", color, h, display.Reset)
+}
+
+// noEstimate explains a missing estimate from the trend it was fitted from
+// and the reason forecast.Project gave
+func noEstimate(trend *float64, reason string) string {
+	msg := "Not declining"
+	switch {
+	case trend == nil:
+		msg = "Not enough readings yet"
+	case reason == forecast.Noisy:
+		msg = "Readings too noisy for an estimate"
+	case reason == forecast.Slow:
+		msg = "Declining too slowly to project"
+	}
+	return display.Dim + msg + display.Reset
+}
+
+// formatHealthChange formats a signed change in health, or "—" if unknown
+func formatHealthChange(v *float64) string {
+	if v == nil {
+		return "—"
+	}
+	return fmt.Sprintf("%+.1f%%", *v)
+}
+
+// untilDate describes how far off a date is, such as "in about 17 months"
+func untilDate(now, t time.Time) string {
+	months := int(t.Sub(now).Hours() / 24 / 30.44)
+	switch {
+	case months < 1:
+		return "within a month"
+	case months < 24:
+		return fmt.Sprintf("in about %d months", months)
+	default:
+		return fmt.Sprintf("in about %d years", months/12)
+	}
+}
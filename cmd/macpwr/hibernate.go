@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/hibernate"
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/settings"
+
+	"github.com/spf13/cobra"
+)
+
+// stdin is read by confirm; tests replace it
+var stdin io.Reader = os.Stdin
+
+// hibernateFlags maps hibernate command flags to pmset keys
+var hibernateFlags = []struct {
+	flag, key, usage string
+}{
+	{"mode", "hibernatemode", "Hibernate mode (0, 3 or 25)"},
+	{"standby", "standby", "Enable standby (1) or disable it (0)"},
+	{"delay-low", "standbydelaylow", "Standby delay below the threshold, in seconds"},
+	{"delay-high", "standbydelayhigh", "Standby delay above the threshold, in seconds"},
+	{"threshold", "highstandbythreshold", "Battery percentage that selects the standby delay"},
+	{"autopoweroff", "autopoweroff", "Enable auto power off (1) or disable it (0)"},
+	{"autopoweroff-delay", "autopoweroffdelay", "Auto power off delay, in seconds"},
+}
+
+func hibernateCmd() *cobra.Command {
+	var ac, bat, ups, all, yes bool
+	values := make(map[string]*int)
+
+	cmd := &cobra.Command{
+		Use:     "hibernate",
+		Aliases: []string{"standby"},
+		Short:   "Show and change hibernation and standby settings",
+		Long: `Show and change hibernation and standby settings, which decide how much
+battery a sleeping Mac uses overnight.
+
+Without flags, shows the current settings, what each hibernate mode does
+and the size of the sleep image. Changes apply to every power source unless
+-a, -b or -u is given.
+
+Examples:
+  macpwr hibernate                      Show settings and explanations
+  macpwr hibernate --mode 25            Hibernate to disk (lowest drain)
+  macpwr hibernate -b --delay-high 3600 Enter standby after 1 h on battery
+  macpwr hibernate --standby 0          Never enter standby`,
+		Run: func(cmd *cobra.Command, args []string) {
+			current, err := settings.Get()
+			if err != nil {
+				display.Error("Failed to read power settings: " + err.Error())
+				return
+			}
+
+			changes := make(map[string]int)
+			for _, f := range hibernateFlags {
+				if cmd.Flags().Changed(f.flag) {
+					changes[f.key] = *values[f.flag]
+				}
+			}
+			if len(changes) == 0 {
+				showHibernate(current)
+				return
+			}
+
+			sources := chosenSources(current, ac, bat, ups, all)
+			if len(sources) == 0 {
+				sources = current.Available()
+			}
+			if !sourcesPresent(current, sources) {
+				return
+			}
+
+			p := plan.New(current)
+			for _, src := range sources {
+				for _, f := range hibernateFlags {
+					if val, ok := changes[f.key]; ok {
+						p.Set(src, f.key, val)
+					}
+				}
+			}
+
+			// Only ask about risks for a plan that will actually be applied
+			if err := p.Validate(); err != nil {
+				reportInvalid(err)
+				return
+			}
+
+			laptop := current.For(settings.Battery) != nil
+			if risks := hibernate.Risks(changes, laptop); len(risks) > 0 && !dryRun && !p.Empty() {
+				for _, r := range risks {
+					display.Warning(r)
+				}
+				if !yes && !confirm("Continue?") {
+					fmt.Println("Nothing was changed.")
+					return
+				}
+			}
+
+			if !applyPlan(p, commandLine(cmd, args), "Failed to apply settings: ") {
+				return
+			}
+			display.Success("Hibernation settings applied")
+		},
+	}
+
+	cmd.Flags().BoolVarP(&ac, "ac", "a", false, "Apply to AC power")
+	cmd.Flags().BoolVarP(&bat, "battery", "b", false, "Apply to battery power")
+	cmd.Flags().BoolVarP(&ups, "ups", "u", false, "Apply to UPS power")
+	cmd.Flags().BoolVar(&all, "all", false, "Apply to every power source (default)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation of risky values")
+	for _, f := range hibernateFlags {
+		values[f.flag] = cmd.Flags().Int(f.flag, 0, f.usage)
+	}
+
+	return cmd
+}
+
+func showHibernate(s *settings.AllSettings) {
+	display.Header("Hibernation & Standby")
+
+	sources := s.Available()
+	var columns []string
+	for _, src := range sources {
+		columns = append(columns, src.Label())
+	}
+
+	display.TableHeader(columns...)
+	for _, k := range settings.KeysIn("Hibernation & Standby") {
+		if !s.Caps.SupportsAny(sources, k.Name) {
+			continue
+		}
+		var values []string
+		for _, src := range sources {
+			values = append(values, sourceSetting(s, src, k))
+		}
+		display.TableRow(k.Label, values...)
+	}
+	display.TableFooter()
+
+	display.Section("Hibernate Modes")
+	for _, m := range hibernate.Modes {
+		var using []string
+		for _, src := range sources {
+			if p := s.For(src); p.Has("hibernatemode") && p.HibernateMode == m.Value {
+				using = append(using, src.Label())
+			}
+		}
+
+		marker := " "
+		if len(using) > 0 {
+			marker = display.Green + "▸" + display.Reset
+		}
+		fmt.Printf("  %s %s%-3d %s%s", marker, display.Bold, m.Value, m.Name, display.Reset)
+		if len(using) > 0 {
+			fmt.Printf("  %s(%s)%s", display.Cyan, strings.Join(using, ", "), display.Reset)
+		}
+		fmt.Println()
+		for _, line := range wrap(m.Description, 64) {
+			fmt.Printf("        %s%s%s\n", display.Dim, line, display.Reset)
+		}
+	}
+
+	display.Section("Settings")
+	for _, k := range settings.KeysIn("Hibernation & Standby") {
+		text, ok := hibernate.Explanations[k.Name]
+		if !ok || !s.Caps.SupportsAny(sources, k.Name) {
+			continue
+		}
+		for i, line := range wrap(text, 50) {
+			label := ""
+			if i == 0 {
+				label = k.Label
+			}
+			fmt.Printf("  %s%-22s%s  %s\n", display.Bold, label, display.Reset, line)
+		}
+	}
+
+	display.Section("Sleep Image")
+	var path string
+	for _, src := range sources {
+		if f := s.For(src).HibernateFile; f != "" {
+			path = f
+			break
+		}
+	}
+	img := hibernate.GetSleepImage(path)
+	display.KV("Path", img.Path)
+	if img.Exists {
+		display.KV("Size", display.FormatBytes(img.Size))
+	} else {
+		display.KV("Size", display.Dim+"not present"+display.Reset)
+	}
+	fmt.Println()
+}
+
+// wrap splits text into lines of at most width characters
+func wrap(text string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// confirm asks a yes/no question and reports whether the answer was yes
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
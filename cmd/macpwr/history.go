@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/journal"
+	"github.com/born1337/macpwr/internal/settings"
+
+	"github.com/spf13/cobra"
+)
+
+func historyCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List changes made by macpwr",
+		Run: func(cmd *cobra.Command, args []string) {
+			entries, err := journal.List()
+			if err != nil {
+				display.Error("Failed to read journal: " + err.Error())
+				return
+			}
+
+			fmt.Printf("\n%sChange History%s\n\n", display.Bold, display.Reset)
+			if len(entries) == 0 {
+				fmt.Printf("  %sNo changes recorded yet%s\n\n", display.Dim, display.Reset)
+				return
+			}
+
+			start := 0
+			if limit > 0 && len(entries) > limit {
+				start = len(entries) - limit
+			}
+			for i := start; i < len(entries); i++ {
+				e := entries[i]
+				fmt.Printf("  %s#%-3d%s %s%s%s  %s\n",
+					display.Cyan, i+1, display.Reset,
+					display.Dim, e.Time.Local().Format("2006-01-02 15:04"), display.Reset,
+					e.Command)
+				for _, c := range e.Changes {
+					fmt.Printf("         %s%-10s %-20s %s → %s%s\n",
+						display.Dim, c.Source.Label(), c.Key, journalValue(c.Before), strconv.Itoa(c.After), display.Reset)
+				}
+			}
+			fmt.Printf("\n%sUndo a change with: macpwr undo <#>%s\n\n", display.Dim, display.Reset)
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "l", 20, "Show only the most recent entries (0 = all)")
+
+	return cmd
+}
+
+func journalValue(v *int) string {
+	if v == nil {
+		return "?"
+	}
+	return strconv.Itoa(*v)
+}
+
+func undoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "undo [n]",
+		Short: "Restore the settings from before a change",
+		Long: `Restore the settings from before change #n in 'macpwr history'.
+
+Change #n and every later change are undone. Without n, the most recent
+change is undone.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			entries, err := journal.List()
+			if err != nil {
+				display.Error("Failed to read journal: " + err.Error())
+				return
+			}
+			if len(entries) == 0 {
+				display.Error("Nothing to undo: no changes recorded")
+				return
+			}
+
+			n := len(entries)
+			if len(args) == 1 {
+				n, err = strconv.Atoi(args[0])
+				if err != nil || n < 1 || n > len(entries) {
+					display.Error(fmt.Sprintf("Invalid change number: %s (use 1-%d)", args[0], len(entries)))
+					return
+				}
+			}
+
+			current, err := settings.Get()
+			if err != nil {
+				display.Error("Failed to read power settings: " + err.Error())
+				return
+			}
+			pl := journal.UndoPlan(entries, n-1, current)
+
+			fmt.Printf("\nUndoing #%d: %s%s%s\n", n, display.Bold, entries[n-1].Command, display.Reset)
+			if n < len(entries) {
+				fmt.Printf("%sAlso undoing %d later change(s)%s\n", display.Dim, len(entries)-n, display.Reset)
+			}
+			fmt.Println()
+
+			if pl.Empty() && !dryRun {
+				display.Success("Settings already match the state before this change")
+				fmt.Println()
+				return
+			}
+			if !applyPlan(pl, commandLine(cmd, args), "Failed to undo: ") {
+				return
+			}
+
+			fmt.Println()
+			display.Success("Change undone")
+			fmt.Println()
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/powerlog"
+
+	"github.com/spf13/cobra"
+)
+
+func logCmd() *cobra.Command {
+	var since, until string
+	var types []string
+
+	cmd := &cobra.Command{
+		Use:   "log",
+		Short: "Show sleep and wake history",
+		Long: `Show sleep, wake, dark wake and assertion events from 'pmset -g log',
+with a summary of time asleep and wake counts.
+
+--since and --until take a duration ago (90m, 24h, 7d) or a date
+(YYYY-MM-DD or "YYYY-MM-DD HH:MM").
+
+Examples:
+  macpwr log                          Events from the last 24 hours
+  macpwr log --since 7d               Events from the last week
+  macpwr log -t wake,darkwake         Only wakes and dark wakes
+  macpwr log --since 2026-10-01 --until 2026-10-02`,
+		Run: func(cmd *cobra.Command, args []string) {
+			filter, err := logFilter(since, until, types)
+			if err != nil {
+				display.Error(err.Error())
+				return
+			}
+
+			events, err := powerlog.Get()
+			if err != nil {
+				display.Error("Failed to read power log: " + err.Error())
+				return
+			}
+			events = filter.Apply(events)
+
+			fmt.Printf("\n%sSleep & Wake History%s  %ssince %s%s\n",
+				display.Bold, display.Reset,
+				display.Dim, filter.Since.Format("2006-01-02 15:04"), display.Reset)
+
+			display.Section("Events")
+			if len(events) == 0 {
+				fmt.Printf("  %sNo events in this period%s\n", display.Dim, display.Reset)
+			}
+			for _, e := range events {
+				printLogEvent(e)
+			}
+
+			s := powerlog.Summarize(events)
+			display.Section("Summary")
+			display.KV("Time Asleep", display.FormatDuration(s.TotalSleep))
+			display.KV("Sleeps", fmt.Sprintf("%d", s.Sleeps))
+			display.KV("Wakes", fmt.Sprintf("%d", s.Wakes))
+			display.KV("Dark Wakes", fmt.Sprintf("%d", s.DarkWakes))
+			if s.Assertions > 0 {
+				display.KV("Assertion Events", fmt.Sprintf("%d", s.Assertions))
+			}
+			fmt.Println()
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "24h", "Show events after this time")
+	cmd.Flags().StringVar(&until, "until", "", "Show events before this time")
+	cmd.Flags().StringSliceVarP(&types, "type", "t", nil, "Event types: sleep, wake, darkwake, assertion")
+
+	return cmd
+}
+
+// logFilter builds a power log filter from the --since, --until and --type flags
+func logFilter(since, until string, types []string) (powerlog.Filter, error) {
+	var f powerlog.Filter
+	now := time.Now()
+
+	var err error
+	if f.Since, err = powerlog.ParseTime(since, now); err != nil {
+		return f, err
+	}
+	if until != "" {
+		if f.Until, err = powerlog.ParseTime(until, now); err != nil {
+			return f, err
+		}
+	}
+	for _, name := range types {
+		t, err := powerlog.ParseEventType(name)
+		if err != nil {
+			return f, err
+		}
+		f.Types = append(f.Types, t)
+	}
+	return f, nil
+}
+
+func printLogEvent(e powerlog.Event) {
+	color := display.Reset
+	switch e.Type {
+	case powerlog.Sleep:
+		color = display.Blue
+	case powerlog.Wake:
+		color = display.Green
+	case powerlog.DarkWake:
+		color = display.Yellow
+	case powerlog.Assertion:
+		color = display.Dim
+	}
+
+	detail := e.Reason
+	if e.Type == powerlog.Assertion {
+		detail = fmt.Sprintf("%s %s %s", e.Process, e.Action, e.AssertionType)
+	}
+
+	duration := ""
+	if e.Duration > 0 && e.Type != powerlog.Assertion {
+		duration = display.FormatDuration(e.Duration)
+	}
+
+	power := ""
+	if e.Source != "" {
+		power = e.Source
+		if e.Charge >= 0 {
+			power += fmt.Sprintf(" %d%%", e.Charge)
+		}
+	}
+
+	line := fmt.Sprintf("  %s%s%s  %s%-9s%s %-40s %8s",
+		display.Dim, e.Time.Format("2006-01-02 15:04"), display.Reset,
+		color, e.Type.Label(), display.Reset,
+		detail, duration)
+	if power != "" {
+		line += "  " + display.Dim + power + display.Reset
+	}
+	fmt.Println(strings.TrimRight(line, " "))
+}
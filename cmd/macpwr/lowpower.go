@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/settings"
+
+	"github.com/spf13/cobra"
+)
+
+func lowpowerCmd() *cobra.Command {
+	var ac, bat, ups, all bool
+
+	cmd := &cobra.Command{
+		Use:       "lowpower [on|off|auto]",
+		Aliases:   []string{"lpm"},
+		Short:     "Show and toggle Low Power Mode",
+		ValidArgs: []string{"on", "off", "auto"},
+		Long: `Show and toggle Low Power Mode, which lowers clock speeds and background
+activity to save energy.
+
+'on' and 'off' apply to every power source unless -a, -b or -u is given.
+'auto' turns Low Power Mode on for battery power and off everywhere else,
+like "Only on Battery" in System Settings.
+
+Examples:
+  macpwr lowpower               Show Low Power Mode per power source
+  macpwr lowpower on -b         Use Low Power Mode on battery
+  macpwr lowpower auto          Only on battery
+  macpwr lowpower off           Turn it off everywhere`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			current, err := settings.Get()
+			if err != nil {
+				display.Error("Failed to read power settings: " + err.Error())
+				return
+			}
+			if len(args) == 0 {
+				showLowPower(current)
+				return
+			}
+
+			sources := chosenSources(current, ac, bat, ups, all)
+			if !sourcesPresent(current, sources) {
+				return
+			}
+			values := make(map[settings.Source]int)
+			switch args[0] {
+			case "on", "off":
+				if len(sources) == 0 {
+					sources = current.Available()
+				}
+				for _, src := range sources {
+					values[src] = 0
+					if args[0] == "on" {
+						values[src] = 1
+					}
+				}
+			case "auto":
+				if len(sources) > 0 {
+					display.Error("'auto' sets every power source; use 'on' or 'off' with -a, -b or -u")
+					return
+				}
+				if current.For(settings.Battery) == nil {
+					display.Error("'auto' needs a battery; use 'on' or 'off' instead")
+					return
+				}
+				for _, src := range current.Available() {
+					values[src] = 0
+					if src == settings.Battery {
+						values[src] = 1
+					}
+				}
+			default:
+				display.Error(fmt.Sprintf("Unknown state %q (use on, off or auto)", args[0]))
+				return
+			}
+
+			p := plan.New(current)
+			for _, src := range current.Available() {
+				if val, ok := values[src]; ok {
+					p.Set(src, "lowpowermode", val)
+				}
+			}
+			if !applyPlan(p, commandLine(cmd, args), "Failed to set Low Power Mode: ") {
+				return
+			}
+
+			after, err := settings.Get()
+			if err != nil {
+				display.Warning("Could not read back power settings: " + err.Error())
+				return
+			}
+			display.Success("Low Power Mode: " + lowPowerState(after))
+		},
+	}
+
+	cmd.Flags().BoolVarP(&ac, "ac", "a", false, "Apply to AC power")
+	cmd.Flags().BoolVarP(&bat, "battery", "b", false, "Apply to battery power")
+	cmd.Flags().BoolVarP(&ups, "ups", "u", false, "Apply to UPS power")
+	cmd.Flags().BoolVar(&all, "all", false, "Apply to every power source (default)")
+
+	return cmd
+}
+
+// lowPowerState summarises lowpowermode across power sources in the terms
+// System Settings uses
+func lowPowerState(s *settings.AllSettings) string {
+	var on, off []settings.Source
+	for _, src := range s.Available() {
+		p := s.For(src)
+		if !p.Has("lowpowermode") || !s.Caps.Supports(src, "lowpowermode") {
+			continue
+		}
+		if p.LowPowerMode {
+			on = append(on, src)
+		} else {
+			off = append(off, src)
+		}
+	}
+
+	switch {
+	case len(on) == 0 && len(off) == 0:
+		return "Not supported"
+	case len(on) == 0:
+		return "Never"
+	case len(off) == 0:
+		return "Always"
+	case len(on) == 1 && on[0] == settings.Battery:
+		return "Only on Battery"
+	case len(on) == 1 && on[0] == settings.AC:
+		return "Only on Power Adapter"
+	}
+
+	state := "On for"
+	for i, src := range on {
+		if i > 0 {
+			state += ","
+		}
+		state += " " + src.Label()
+	}
+	return state
+}
+
+func showLowPower(s *settings.AllSettings) {
+	display.Header("Low Power Mode")
+
+	k, _ := settings.LookupKey("lowpowermode")
+	display.Section("Settings")
+	for _, src := range s.Available() {
+		display.KV(src.Label(), sourceSetting(s, src, k))
+	}
+	display.KV("Mode", lowPowerState(s))
+
+	if src, ok := settings.ActiveSource(); ok && s.For(src) != nil {
+		state := sourceSetting(s, src, k)
+		display.KV("Now", fmt.Sprintf("%s %s(%s in use)%s", state, display.Dim, src.Label(), display.Reset))
+	}
+	fmt.Println()
+}
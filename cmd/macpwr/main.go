@@ -3,15 +3,21 @@ package main
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/born1337/macpwr/internal/assertions"
 	"github.com/born1337/macpwr/internal/battery"
 	"github.com/born1337/macpwr/internal/caffeinate"
 	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/plist"
+	"github.com/born1337/macpwr/internal/powersource"
 	"github.com/born1337/macpwr/internal/presets"
 	"github.com/born1337/macpwr/internal/profiles"
+	"github.com/born1337/macpwr/internal/runner"
 	"github.com/born1337/macpwr/internal/settings"
 	"github.com/born1337/macpwr/internal/thermal"
 
@@ -21,6 +27,14 @@ import (
 const version = "1.0.0"
 
 func main() {
+	runner.Default = runner.FromEnv()
+
+	if err := newRootCmd().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:     "macpwr",
 		Short:   "macOS Power Management CLI",
@@ -29,20 +43,32 @@ func main() {
 		Run:     runStatus,
 	}
 
+	rootCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "n", false, "Print the privileged commands instead of running them")
+
 	// Add subcommands
 	rootCmd.AddCommand(statusCmd())
 	rootCmd.AddCommand(showCmd())
 	rootCmd.AddCommand(setCmd())
 	rootCmd.AddCommand(batteryCmd())
+	rootCmd.AddCommand(chargerCmd())
 	rootCmd.AddCommand(presetCmd())
 	rootCmd.AddCommand(profileCmd())
+	rootCmd.AddCommand(diffCmd())
+	rootCmd.AddCommand(exportCmd())
+	rootCmd.AddCommand(historyCmd())
+	rootCmd.AddCommand(undoCmd())
+	rootCmd.AddCommand(enforceCmd())
+	rootCmd.AddCommand(scheduleCmd())
+	rootCmd.AddCommand(hibernateCmd())
+	rootCmd.AddCommand(logCmd())
+	rootCmd.AddCommand(wakesCmd())
+	rootCmd.AddCommand(upsCmd())
+	rootCmd.AddCommand(lowpowerCmd())
 	rootCmd.AddCommand(caffeinateCmd())
 	rootCmd.AddCommand(assertionsCmd())
 	rootCmd.AddCommand(thermalCmd())
 
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
-	}
+	return rootCmd
 }
 
 func statusCmd() *cobra.Command {
@@ -57,10 +83,15 @@ func runStatus(cmd *cobra.Command, args []string) {
 	fmt.Printf("\n%smacpwr%s %sv%s%s\n\n", display.Bold, display.Reset, display.Dim, version, display.Reset)
 
 	// Power source
-	source := settings.GetPowerSource()
-	if source == "AC Power" {
+	ps, _ := powersource.Get()
+	switch {
+	case ps == nil:
+		fmt.Printf("  Power: %sUnknown%s\n", display.Dim, display.Reset)
+	case ps.OnAC():
 		fmt.Printf("  Power: %s⚡ AC Power%s\n", display.Green, display.Reset)
-	} else {
+	case ps.OnUPS():
+		fmt.Printf("  Power: %s🔌 UPS%s\n", display.Red, display.Reset)
+	default:
 		fmt.Printf("  Power: %s🔋 Battery%s\n", display.Yellow, display.Reset)
 	}
 
@@ -70,23 +101,38 @@ func runStatus(cmd *cobra.Command, args []string) {
 		health := info.HealthPercent()
 
 		statusIcon := ""
-		if info.IsCharging {
+		if ps != nil && ps.InternalBattery() != nil {
+			if state := ps.InternalBattery().State; state != powersource.Discharging && state != powersource.Unknown {
+				statusIcon = " (" + strings.ToLower(state.Label()) + ")"
+			}
+		} else if info.IsCharging {
 			statusIcon = " (charging)"
 		}
 
 		fmt.Printf("  Battery: %s%s\n", display.FormatPercent(percent), statusIcon)
 		fmt.Printf("  Health: %s (%d cycles)\n", display.FormatPercent(health), info.CycleCount)
+
+		if line := drawSummary(info); line != "" {
+			fmt.Printf("  Draw: %s\n", line)
+		}
+	}
+
+	if ps != nil {
+		if ups := ps.UPS(); ups != nil {
+			fmt.Printf("  UPS: %s (%s)\n", display.FormatPercent(ups.Percent), strings.ToLower(ups.State.Label()))
+		}
 	}
 
 	// AC settings summary
-	if s, err := settings.Get(); err == nil {
+	if s, err := settings.Get(); err == nil && s.For(settings.AC) != nil {
+		ac := s.For(settings.AC)
 		fmt.Printf("\n  %sAC Settings:%s Display %s, Sleep %s\n",
 			display.Dim, display.Reset,
-			display.FormatTime(s.AC.DisplaySleep),
-			display.FormatTime(s.AC.SystemSleep))
+			display.FormatTime(ac.DisplaySleep),
+			display.FormatTime(ac.SystemSleep))
 	}
 
-	fmt.Printf("\n%sCommands: show, set, battery, preset, profile, caffeinate, assertions, thermal%s\n", display.Dim, display.Reset)
+	fmt.Printf("\n%sCommands: show, set, battery, charger, preset, profile, diff, export, history, enforce, schedule, hibernate, log, wakes, ups, lowpower, caffeinate, assertions, thermal%s\n", display.Dim, display.Reset)
 	fmt.Printf("%sRun 'macpwr help' for more information%s\n\n", display.Dim, display.Reset)
 }
 
@@ -103,54 +149,223 @@ func showCmd() *cobra.Command {
 				return
 			}
 
-			display.TableHeader()
-			display.TableRow("Display Sleep",
-				display.FormatTime(s.Battery.DisplaySleep),
-				display.FormatTime(s.AC.DisplaySleep))
-			display.TableRow("System Sleep",
-				display.FormatTime(s.Battery.SystemSleep),
-				display.FormatTime(s.AC.SystemSleep))
-			display.TableRow("Disk Sleep",
-				display.FormatTime(s.Battery.DiskSleep),
-				display.FormatTime(s.AC.DiskSleep))
-			display.TableSep()
-			display.TableRow("Power Nap",
-				display.FormatBool(s.Battery.PowerNap),
-				display.FormatBool(s.AC.PowerNap))
-			display.TableRow("Wake on LAN",
-				display.FormatBool(s.Battery.WakeOnLAN),
-				display.FormatBool(s.AC.WakeOnLAN))
-			display.TableRow("Low Power Mode",
-				display.FormatBool(s.Battery.LowPowerMode),
-				display.FormatBool(s.AC.LowPowerMode))
-			display.TableRow("TCP Keep Alive",
-				display.FormatBool(s.Battery.TCPKeepAlive),
-				display.FormatBool(s.AC.TCPKeepAlive))
+			sources := s.Available()
+			var columns []string
+			for _, src := range sources {
+				columns = append(columns, src.Label())
+			}
+
+			// The effective column is only shown when pmset -g can be read
+			eff, err := settings.GetEffective()
+			if err == nil {
+				columns = append(columns, "Effective")
+			}
+			var configured *settings.PowerSettings
+			if eff != nil {
+				configured = s.For(eff.Source)
+			}
+			var overridden []settings.Key
+
+			display.TableHeader(columns...)
+			first := true
+			for _, category := range settings.Categories {
+				var rows []settings.Key
+				for _, k := range settings.KeysIn(category) {
+					if !s.Caps.SupportsAny(sources, k.Name) {
+						continue
+					}
+					for _, src := range sources {
+						if s.For(src).Has(k.Name) {
+							rows = append(rows, k)
+							break
+						}
+					}
+				}
+				if len(rows) == 0 {
+					continue
+				}
+
+				if !first {
+					display.TableSep()
+				}
+				first = false
+				display.TableGroup(category)
+				for _, k := range rows {
+					var values []string
+					for _, src := range sources {
+						values = append(values, sourceSetting(s, src, k))
+					}
+					if eff != nil && !s.Caps.Supports(eff.Source, k.Name) {
+						values = append(values, display.Dim+"n/a"+display.Reset)
+					} else if eff != nil {
+						value := formatSetting(k, eff.Settings)
+						if eff.Overridden(k.Name, configured) {
+							value += display.Yellow + " *" + display.Reset
+							overridden = append(overridden, k)
+						}
+						values = append(values, value)
+					}
+					display.TableRow(k.Label, values...)
+				}
+			}
+
+			other := otherKeys(s)
+			if len(other) > 0 {
+				display.TableSep()
+				display.TableGroup("Other")
+				for _, key := range other {
+					var values []string
+					for _, src := range sources {
+						values = append(values, orDash(s.For(src).Other[key]))
+					}
+					if eff != nil {
+						values = append(values, orDash(eff.Settings.Other[key]))
+					}
+					display.TableRow(key, values...)
+				}
+			}
 			display.TableFooter()
+
+			if len(overridden) > 0 {
+				printOverrides(eff, configured, overridden)
+			}
 			fmt.Println()
 		},
 	}
 }
 
+// printOverrides explains each setting whose effective value differs from
+// the configured one
+func printOverrides(eff *settings.Effective, configured *settings.PowerSettings, keys []settings.Key) {
+	inUse := ""
+	if eff.Source != "" {
+		inUse = " (" + eff.Source.Label() + " in use)"
+	}
+	fmt.Printf("\n%s*%s Overridden settings%s:\n", display.Yellow, display.Reset, inUse)
+
+	for _, k := range keys {
+		if o, ok := eff.Overrides[k.Name]; ok {
+			fmt.Printf("  %-22s %s\n", k.Label+":", o.Reason)
+			continue
+		}
+		fmt.Printf("  %-22s configured %s, in effect %s\n", k.Label+":",
+			formatSetting(k, configured), formatSetting(k, eff.Settings))
+	}
+}
+
+// chosenSources returns the power sources selected by the -a, -b, -u and
+// --all flags, or nil if none was given
+func chosenSources(current *settings.AllSettings, ac, bat, ups, all bool) []settings.Source {
+	if all && current != nil {
+		return current.Available()
+	}
+
+	var sources []settings.Source
+	if bat {
+		sources = append(sources, settings.Battery)
+	}
+	if ac || all {
+		sources = append(sources, settings.AC)
+	}
+	if ups {
+		sources = append(sources, settings.UPS)
+	}
+	return sources
+}
+
+// sourcesPresent reports an error and returns false if pmset has no
+// settings for one of the chosen power sources, such as -u on a Mac
+// without a UPS
+func sourcesPresent(current *settings.AllSettings, sources []settings.Source) bool {
+	for _, src := range sources {
+		if current.For(src) == nil {
+			display.Error(fmt.Sprintf("No %s settings found: pmset reports no %s section", src.Label(), src.Label()))
+			return false
+		}
+	}
+	return true
+}
+
+// sourceSetting formats a key for one power source, or "n/a" when this Mac
+// does not support the key on that source
+func sourceSetting(s *settings.AllSettings, src settings.Source, k settings.Key) string {
+	if !s.Caps.Supports(src, k.Name) {
+		return display.Dim + "n/a" + display.Reset
+	}
+	return formatSetting(k, s.For(src))
+}
+
+// formatSetting renders a power source's setting according to its kind
+func formatSetting(k settings.Key, p *settings.PowerSettings) string {
+	if !p.Has(k.Name) {
+		return "—"
+	}
+	val, _ := p.Value(k.Name)
+	return formatValue(k, val)
+}
+
+// formatValue renders a setting value according to its kind
+func formatValue(k settings.Key, val int) string {
+	switch k.Kind {
+	case settings.Minutes:
+		return display.FormatTime(val)
+	case settings.Seconds:
+		return display.FormatSeconds(val)
+	case settings.Bool:
+		return display.FormatBool(val == 1)
+	case settings.Percent:
+		return fmt.Sprintf("%d%%", val)
+	case settings.Enum:
+		if name, ok := k.Choices[val]; ok {
+			return name
+		}
+	}
+	return strconv.Itoa(val)
+}
+
+// otherKeys returns the sorted set of unmodelled keys across power sources
+func otherKeys(s *settings.AllSettings) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, src := range s.Available() {
+		for key := range s.For(src).Other {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
 func setCmd() *cobra.Command {
-	var ac, bat bool
+	var ac, bat, ups, all bool
 	var displaySleep, systemSleep, diskSleep int
 
 	cmd := &cobra.Command{
 		Use:   "set",
 		Short: "Change power settings",
-		Long: `Change power settings for AC or battery power.
+		Long: `Change power settings for AC, battery or UPS power.
+
+Source flags can be combined; all changes are applied with at most one
+password prompt.
 
 Examples:
   macpwr set -a -d 60 -s 60     Set AC: display & sleep to 60 min
   macpwr set -b -d 10 -s 5      Set Battery: display 10, sleep 5 min
+  macpwr set -u -s 5            Set UPS: sleep 5 min
+  macpwr set -a -b -k 10        Set AC and Battery: disk sleep 10 min
+  macpwr set --all -d 15        Set every power source: display 15 min
   macpwr set --ac --display 0   Set AC display to never sleep`,
 		Run: func(cmd *cobra.Command, args []string) {
-			// Default to AC if neither specified
-			if !ac && !bat {
-				ac = true
-			}
-
 			dFlag := cmd.Flags().Changed("display")
 			sFlag := cmd.Flags().Changed("sleep")
 			kFlag := cmd.Flags().Changed("disk")
@@ -161,29 +376,37 @@ Examples:
 				return
 			}
 
-			opts := settings.SetOptions{}
-			if ac {
-				opts.PowerSource = "-c"
-			} else {
-				opts.PowerSource = "-b"
+			current, err := settings.Get()
+			if err != nil {
+				display.Error("Failed to read power settings: " + err.Error())
+				return
 			}
 
-			if dFlag {
-				opts.DisplaySleep = &displaySleep
-			}
-			if sFlag {
-				opts.SystemSleep = &systemSleep
+			// Default to AC if no source specified
+			sources := chosenSources(current, ac, bat, ups, all)
+			if len(sources) == 0 {
+				sources = []settings.Source{settings.AC}
 			}
-			if kFlag {
-				opts.DiskSleep = &diskSleep
+			if !sourcesPresent(current, sources) {
+				return
 			}
 
-			sourceName := "AC Power"
-			if bat {
-				sourceName = "Battery"
+			p := plan.New(current)
+			var labels []string
+			for _, source := range sources {
+				if dFlag {
+					p.Set(source, "displaysleep", displaySleep)
+				}
+				if sFlag {
+					p.Set(source, "sleep", systemSleep)
+				}
+				if kFlag {
+					p.Set(source, "disksleep", diskSleep)
+				}
+				labels = append(labels, source.Label())
 			}
 
-			fmt.Printf("\nApplying to %s%s%s:\n", display.Bold, sourceName, display.Reset)
+			fmt.Printf("\nApplying to %s%s%s:\n", display.Bold, strings.Join(labels, ", "), display.Reset)
 			if dFlag {
 				fmt.Printf("  • Display sleep: %s\n", display.FormatTime(displaySleep))
 			}
@@ -195,8 +418,7 @@ Examples:
 			}
 			fmt.Println()
 
-			if err := settings.Set(opts); err != nil {
-				display.Error("Failed to apply settings: " + err.Error())
+			if !applyPlan(p, commandLine(cmd, args), "Failed to apply settings: ") {
 				return
 			}
 
@@ -207,6 +429,8 @@ Examples:
 
 	cmd.Flags().BoolVarP(&ac, "ac", "a", false, "Apply to AC power")
 	cmd.Flags().BoolVarP(&bat, "battery", "b", false, "Apply to battery power")
+	cmd.Flags().BoolVarP(&ups, "ups", "u", false, "Apply to UPS power")
+	cmd.Flags().BoolVar(&all, "all", false, "Apply to every power source")
 	cmd.Flags().IntVarP(&displaySleep, "display", "d", 0, "Set display sleep (0 = never)")
 	cmd.Flags().IntVarP(&systemSleep, "sleep", "s", 0, "Set system sleep (0 = never)")
 	cmd.Flags().IntVarP(&diskSleep, "disk", "k", 0, "Set disk sleep (0 = never)")
@@ -215,13 +439,21 @@ Examples:
 }
 
 func batteryCmd() *cobra.Command {
-	return &cobra.Command{
+	var raw bool
+
+	cmd := &cobra.Command{
 		Use:     "battery",
 		Aliases: []string{"batt"},
 		Short:   "Show detailed battery information",
-		Run: func(cmd *cobra.Command, args []string) {
-			display.Header("Battery Information")
+		Long: `Show detailed battery information from the IOKit registry.
 
+Examples:
+  macpwr battery                Show charge, health and details
+  macpwr battery --raw          Dump every key ioreg reports
+  macpwr battery log            Record samples to the battery log
+  macpwr battery history        Show trends from the battery log
+  macpwr battery forecast       Estimate when health reaches 80%`,
+		Run: func(cmd *cobra.Command, args []string) {
 			info, err := battery.GetInfo()
 			if err != nil {
 				display.Error("Failed to read battery info: " + err.Error())
@@ -232,25 +464,45 @@ func batteryCmd() *cobra.Command {
 				return
 			}
 
+			if raw {
+				printRaw(info.Raw, "")
+				return
+			}
+
+			display.Header("Battery Information")
+
 			display.Section("Charge")
 			display.KV("Level", display.FormatPercent(info.ChargePercent()))
 
-			status := info.Status()
-			switch status {
-			case "Fully Charged":
-				display.KV("Status", display.Green+status+display.Reset)
-			case "Charging":
-				display.KV("Status", display.Yellow+status+display.Reset)
-			case "On AC Power":
-				display.KV("Status", display.Cyan+status+display.Reset)
-			default:
-				display.KV("Status", display.Blue+status+display.Reset)
+			// pmset -g ps reports states ioreg does not, such as
+			// "finishing charge" and "not charging"
+			var src *powersource.Source
+			if ps, err := powersource.Get(); err == nil {
+				src = ps.InternalBattery()
 			}
 
-			display.KV("Time", info.TimeRemainingFormatted())
+			if src != nil {
+				display.KV("Status", stateColor(src.State)+src.State.Label()+display.Reset)
+				display.KV("Time", formatRemaining(src))
+			} else {
+				status := info.Status()
+				switch status {
+				case "Fully Charged":
+					display.KV("Status", display.Green+status+display.Reset)
+				case "Charging":
+					display.KV("Status", display.Yellow+status+display.Reset)
+				case "On AC Power":
+					display.KV("Status", display.Cyan+status+display.Reset)
+				default:
+					display.KV("Status", display.Blue+status+display.Reset)
+				}
+				display.KV("Time", info.TimeRemainingFormatted())
+			}
 			display.KV("Current Capacity", fmt.Sprintf("%d mAh", info.ActualCurrentCapacity()))
 			display.KV("Max Capacity", fmt.Sprintf("%d mAh", info.ActualMaxCapacity()))
 
+			printDraw(info)
+
 			display.Section("Health")
 			display.KV("Health", display.FormatPercent(info.HealthPercent()))
 			display.KV("Cycle Count", strconv.Itoa(info.CycleCount))
@@ -259,10 +511,120 @@ func batteryCmd() *cobra.Command {
 			display.Section("Details")
 			display.KV("Temperature", fmt.Sprintf("%d°C", info.TemperatureCelsius()))
 			display.KV("AC Connected", display.FormatBool(info.ExternalConnected))
+			if made, ok := info.Manufactured(); ok {
+				display.KV("Manufactured", made.Format("2006-01-02"))
+			}
+			if info.Serial != "" {
+				display.KV("Serial", info.Serial)
+			}
 
 			fmt.Println()
 		},
 	}
+
+	cmd.Flags().BoolVar(&raw, "raw", false, "Dump every key ioreg reports for the battery")
+	cmd.AddCommand(batteryLogCmd(), batteryHistoryCmd(), batteryForecastCmd())
+
+	return cmd
+}
+
+// drawSummary describes the power draw in one line for status
+func drawSummary(info *battery.Info) string {
+	d := info.Draw()
+	var parts []string
+	if d.System > 0 {
+		parts = append(parts, display.FormatWatts(d.System))
+	}
+	if d.Battery != 0 {
+		parts = append(parts, "battery "+batteryRate(d.Battery))
+	}
+	line := strings.Join(parts, ", ")
+	if info.Deficit() {
+		line += " " + display.Yellow + "(adapter cannot keep up)" + display.Reset
+	}
+	return line
+}
+
+// printDraw prints the Power section of the battery command
+func printDraw(info *battery.Info) {
+	d := info.Draw()
+	display.Section("Power")
+
+	switch {
+	case d.System > 0 && d.Measured:
+		display.KV("System", display.FormatWatts(d.System))
+	case d.System > 0:
+		display.KV("System", display.FormatWatts(d.System)+" "+display.Dim+"(estimated)"+display.Reset)
+	default:
+		display.KV("System", "—")
+	}
+	display.KV("Battery", batteryRate(d.Battery))
+	if a := info.AdapterDetails; a.Connected() {
+		input := display.FormatWatts(d.Adapter)
+		if a.AdapterVoltage > 0 && a.Current > 0 {
+			input += " " + display.Dim + "(" + formatVoltsAmps(a.AdapterVoltage, a.Current) + ")" + display.Reset
+		}
+		display.KV("Adapter Input", input)
+	}
+	if info.Deficit() {
+		display.Warning(fmt.Sprintf("Draining %s faster than the adapter supplies", display.FormatWatts(-d.Battery)))
+	}
+}
+
+// batteryRate formats a signed battery power, such as "+18.4 W (charging)"
+func batteryRate(w float64) string {
+	switch {
+	case w > 0:
+		return fmt.Sprintf("%s+%s%s (charging)", display.Green, display.FormatWatts(w), display.Reset)
+	case w < 0:
+		return fmt.Sprintf("%s%s%s (discharging)", display.Yellow, display.FormatWatts(w), display.Reset)
+	}
+	return display.FormatWatts(0)
+}
+
+// printRaw prints a plist dictionary with sorted keys, indenting nested
+// dictionaries and arrays
+func printRaw(d plist.Dict, indent string) {
+	keys := make([]string, 0, len(d))
+	width := 0
+	for key := range d {
+		keys = append(keys, key)
+		width = max(width, len(key))
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		printRawValue(indent, fmt.Sprintf("%-*s", width, key), d[key])
+	}
+}
+
+func printRawValue(indent, label string, v any) {
+	switch v := v.(type) {
+	case plist.Dict:
+		fmt.Printf("%s%s%s%s\n", indent, display.Cyan, strings.TrimRight(label, " "), display.Reset)
+		printRaw(v, indent+"  ")
+	case []any:
+		var scalars []string
+		for _, item := range v {
+			if _, ok := item.(plist.Dict); ok {
+				scalars = nil
+				break
+			}
+			scalars = append(scalars, fmt.Sprint(item))
+		}
+		if len(scalars) == len(v) {
+			fmt.Printf("%s%s  [%s]\n", indent, label, strings.Join(scalars, ", "))
+			return
+		}
+		fmt.Printf("%s%s%s%s\n", indent, display.Cyan, strings.TrimRight(label, " "), display.Reset)
+		for i, item := range v {
+			printRawValue(indent+"  ", fmt.Sprintf("[%d]", i), item)
+		}
+	case []byte:
+		fmt.Printf("%s%s  %x\n", indent, label, v)
+	default:
+		fmt.Printf("%s%s  %v\n", indent, label, v)
+	}
 }
 
 func presetCmd() *cobra.Command {
@@ -299,11 +661,14 @@ Available presets:
 			fmt.Printf("\nApplying preset: %s%s%s\n", display.Bold, p.Name, display.Reset)
 			fmt.Printf("%s%s%s\n\n", display.Dim, p.Description, display.Reset)
 
-			fmt.Println("Setting AC Power...")
-			fmt.Println("Setting Battery Power...")
+			current, err := settings.Get()
+			if err != nil {
+				display.Error("Failed to read power settings: " + err.Error())
+				return
+			}
+			pl := p.Plan(current)
 
-			if err := presets.Apply(p); err != nil {
-				display.Error("Failed to apply preset: " + err.Error())
+			if !applyPlan(pl, commandLine(cmd, args), "Failed to apply preset: ") {
 				return
 			}
 
@@ -342,18 +707,25 @@ func profileCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			name := args[0]
+			if !profiles.Exists(name) {
+				display.Error("Profile not found: " + name)
+				return
+			}
 			p, err := profiles.Load(name)
 			if err != nil {
-				display.Error("Profile not found: " + name)
+				display.Error(err.Error())
 				return
 			}
 
-			fmt.Printf("\nLoading profile: %s%s%s\n\n", display.Bold, name, display.Reset)
-			fmt.Println("Setting Battery Power...")
-			fmt.Println("Setting AC Power...")
+			current, err := settings.Get()
+			if err != nil {
+				display.Error("Failed to read power settings: " + err.Error())
+				return
+			}
+			pl := p.Plan(current)
 
-			if err := profiles.Apply(p); err != nil {
-				display.Error(err.Error())
+			fmt.Printf("\nLoading profile: %s%s%s\n\n", display.Bold, name, display.Reset)
+			if !applyPlan(pl, commandLine(cmd, args), "Failed to load profile: ") {
 				return
 			}
 
@@ -512,7 +884,7 @@ func assertionsCmd() *cobra.Command {
 				fmt.Printf("  %sNo scheduled wake/sleep events%s\n", display.Dim, display.Reset)
 			} else {
 				for _, e := range info.Scheduled {
-					fmt.Printf("  • %s\n", e.Description)
+					fmt.Printf("  • %s\n", e)
 				}
 			}
 
@@ -521,6 +893,39 @@ func assertionsCmd() *cobra.Command {
 	}
 }
 
+// stateColor returns the colour used for a charging state
+func stateColor(state powersource.State) string {
+	switch state {
+	case powersource.Charged:
+		return display.Green
+	case powersource.Charging, powersource.FinishingCharge:
+		return display.Yellow
+	case powersource.NotCharging:
+		return display.Cyan
+	}
+	return display.Blue
+}
+
+// formatRemaining formats the time pmset estimates until a source is full
+// or empty
+func formatRemaining(src *powersource.Source) string {
+	if src.TimeRemaining < 0 || (src.TimeRemaining == 0 && src.State != powersource.Charged) {
+		if src.State == powersource.NotCharging {
+			return "—"
+		}
+		return "Calculating..."
+	}
+	if src.State == powersource.Charged {
+		return "—"
+	}
+
+	t := fmt.Sprintf("%dh %dm", src.TimeRemaining/60, src.TimeRemaining%60)
+	if src.Charging() {
+		return t + " until full"
+	}
+	return t + " remaining"
+}
+
 func thermalCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:     "thermal",
@@ -555,12 +960,13 @@ func thermalCmd() *cobra.Command {
 			}
 
 			display.Section("Power")
-			if info.PowerSource == "AC Power" {
+			switch {
+			case info.Power == nil:
+				display.KV("Power Source", "Unknown")
+			case info.Power.OnAC():
 				display.KV("Power Source", display.Green+"AC Power"+display.Reset)
-			} else if info.PowerSource == "Battery" {
-				display.KV("Power Source", display.Yellow+"Battery"+display.Reset)
-			} else {
-				display.KV("Power Source", info.PowerSource)
+			default:
+				display.KV("Power Source", display.Yellow+info.Power.Label()+display.Reset)
 			}
 
 			if info.CPULimit > 0 {
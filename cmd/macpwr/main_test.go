@@ -0,0 +1,551 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/born1337/macpwr/internal/batterylog"
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/forecast"
+	"github.com/born1337/macpwr/internal/runner"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// realHome is the user's home directory; tests never write to it
+var realHome = os.Getenv("HOME")
+
+// runCLI runs macpwr against a fixture set and returns its stdout
+func runCLI(t *testing.T, fixtures string, args ...string) (string, *runner.Replay) {
+	t.Helper()
+
+	out, replay, err := execCLI(t, fixtures, args...)
+	if err != nil {
+		t.Fatalf("macpwr %v: %v", args, err)
+	}
+	return out, replay
+}
+
+// execCLI runs macpwr against a fixture set and returns the command error
+// instead of failing the test
+func execCLI(t *testing.T, fixtures string, args ...string) (string, *runner.Replay, error) {
+	t.Helper()
+
+	if os.Getenv("HOME") == realHome {
+		t.Setenv("HOME", t.TempDir())
+	}
+
+	replay := runner.NewReplay(filepath.Join("testdata", "fixtures", fixtures))
+	orig := runner.Default
+	runner.Default = replay
+	defer func() { runner.Default = orig }()
+
+	old := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	cmd := newRootCmd()
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+
+	w.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	return buf.String(), replay, err
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "golden", name+".txt")
+	if *update {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file (run go test -update): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	display.DisableColor()
+
+	// Times such as --since are read in the local zone while fixture events
+	// carry their own offsets; pin the zone so goldens match on every machine
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+
+	tests := []struct {
+		name     string
+		fixtures string
+		args     []string
+	}{
+		{"status", "macbook", []string{"status"}},
+		{"show", "macbook", []string{"show"}},
+		{"battery", "macbook", []string{"battery"}},
+		{"battery_raw", "macbook", []string{"battery", "--raw"}},
+		{"charger", "macbook", []string{"charger"}},
+		{"assertions", "macbook", []string{"assertions"}},
+		{"thermal", "macbook", []string{"thermal"}},
+		{"preset_list", "macbook", []string{"preset", "list"}},
+		{"diff_preset", "macbook", []string{"diff", "battery-saver"}},
+		{"diff_preset_json", "macbook", []string{"diff", "movie", "--json"}},
+		{"set_all_dry_run", "macbook", []string{"--dry-run", "set", "--all", "-d", "0", "-s", "0"}},
+		{"preset_dry_run", "macbook", []string{"--dry-run", "preset", "battery-saver"}},
+		{"schedule", "macbook", []string{"schedule"}},
+		{"hibernate", "macbook", []string{"hibernate"}},
+		{"log", "macbook", []string{"log", "--since", "2026-10-14"}},
+		{"wakes", "macbook", []string{"wakes", "--since", "2026-10-14"}},
+		{"ups", "imac-ups", []string{"ups"}},
+		{"show_ups", "imac-ups", []string{"show"}},
+		{"preset_dry_run_ups", "imac-ups", []string{"--dry-run", "preset", "battery-saver"}},
+		{"export", "macbook", []string{"export", "--format", "sh"}},
+		{"export_preset_ups", "imac-ups", []string{"export", "battery-saver"}},
+		{"lowpower", "macbook", []string{"lowpower"}},
+		{"lowpower_auto_dry_run", "macbook", []string{"--dry-run", "lowpower", "auto"}},
+		{"log_wakes", "macbook", []string{"log", "--since", "2026-10-15", "--until", "2026-10-16", "-t", "wake,darkwake"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := runCLI(t, tt.fixtures, tt.args...)
+			checkGolden(t, tt.name, got)
+		})
+	}
+}
+
+func TestSetUsesSudoPmset(t *testing.T) {
+	display.DisableColor()
+
+	_, replay := runCLI(t, "macbook", "set", "-b", "-d", "5", "-s", "10")
+
+	want := "sudo pmset -b displaysleep 5 sleep 10"
+	if len(replay.Calls) != 1 || replay.Calls[0] != want {
+		t.Errorf("Calls = %q, want [%q]", replay.Calls, want)
+	}
+}
+
+func TestDryRunDoesNotExecute(t *testing.T) {
+	display.DisableColor()
+
+	for _, args := range [][]string{
+		{"--dry-run", "set", "-b", "-d", "5", "-s", "10"},
+		{"--dry-run", "preset", "presentation"},
+	} {
+		out, replay := runCLI(t, "macbook", args...)
+		if len(replay.Calls) != 0 {
+			t.Errorf("macpwr %v executed %q", args, replay.Calls)
+		}
+		if !strings.Contains(out, "sudo pmset") {
+			t.Errorf("macpwr %v did not print the pmset command:\n%s", args, out)
+		}
+	}
+}
+
+func TestJournalRecordsChanges(t *testing.T) {
+	display.DisableColor()
+	t.Setenv("HOME", t.TempDir())
+
+	runCLI(t, "macbook", "set", "-b", "-d", "1")
+	runCLI(t, "macbook", "--dry-run", "set", "-b", "-d", "0")
+
+	out, _ := runCLI(t, "macbook", "history")
+	if !strings.Contains(out, "#1   ") || !strings.Contains(out, "set --battery --display=1") {
+		t.Errorf("history does not list the set command:\n%s", out)
+	}
+	if strings.Contains(out, "#2") {
+		t.Errorf("dry runs must not be journaled:\n%s", out)
+	}
+	if !strings.Contains(out, "displaysleep         2 → 1") {
+		t.Errorf("history does not show before/after values:\n%s", out)
+	}
+}
+
+func TestEnforceCheck(t *testing.T) {
+	display.DisableColor()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".config", "macpwr", "profiles")
+	os.MkdirAll(dir, 0755)
+	os.WriteFile(filepath.Join(dir, "pinned.profile"), []byte("[battery]\ndisplaysleep=2\nsleep=1\ndisksleep=10\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "drifted.profile"), []byte("[battery]\ndisplaysleep=5\nsleep=1\ndisksleep=10\n"), 0644)
+
+	if _, _, err := execCLI(t, "macbook", "enforce", "pinned", "--check"); err != nil {
+		t.Errorf("enforce --check on matching settings: %v", err)
+	}
+
+	for _, i := range []string{"0", "-1m"} {
+		if _, _, err := execCLI(t, "macbook", "enforce", "pinned", "-i", i); err == nil {
+			t.Errorf("enforce -i %s was accepted", i)
+		}
+	}
+
+	out, replay, err := execCLI(t, "macbook", "enforce", "drifted", "--check")
+	if err != errDrift {
+		t.Errorf("enforce --check on drift returned %v, want errDrift", err)
+	}
+	if !strings.Contains(out, "Battery displaysleep is 2, pinned 5") {
+		t.Errorf("drift not reported:\n%s", out)
+	}
+	if len(replay.Calls) != 0 {
+		t.Errorf("enforce --check without --fix ran %v", replay.Calls)
+	}
+
+	log, err := os.ReadFile(filepath.Join(home, ".config", "macpwr", "drift.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(log), "drifted Battery displaysleep=2 pinned=5 detected") {
+		t.Errorf("drift log = %q", log)
+	}
+
+	// This iMac's pmset -g custom has no autopoweroff and there is no
+	// capabilities probe to skip it, so it can't be compared and is not drift
+	os.WriteFile(filepath.Join(dir, "unreported.profile"), []byte("[ac]\ndisplaysleep=10\nsleep=0\ndisksleep=10\nautopoweroff=1\n"), 0644)
+	out, replay, err = execCLI(t, "imac-ups", "enforce", "unreported", "--check", "--fix")
+	if err != nil {
+		t.Errorf("enforce --check with an unreported key returned %v", err)
+	}
+	if !strings.Contains(out, "AC Power autopoweroff is not reported") {
+		t.Errorf("unreported key not mentioned:\n%s", out)
+	}
+	if len(replay.Calls) != 0 {
+		t.Errorf("enforce --fix reapplied an unreported key: %v", replay.Calls)
+	}
+
+	// autopoweroff is not supported on this MacBook, so the profile skips it
+	os.WriteFile(filepath.Join(dir, "unreported.profile"), []byte("[battery]\ndisplaysleep=1\nsleep=1\ndisksleep=10\nautopoweroff=1\n"), 0644)
+	_, replay, _ = execCLI(t, "macbook", "enforce", "unreported", "--check", "--fix")
+	want := []string{"sudo pmset -b displaysleep 1"}
+	if !reflect.DeepEqual(replay.Calls, want) {
+		t.Errorf("enforce --fix Calls = %q, want %q", replay.Calls, want)
+	}
+}
+
+func TestScheduleCommands(t *testing.T) {
+	display.DisableColor()
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"schedule", "add", "wake", "2099-01-02 07:00", "--owner", "backup"},
+			"sudo pmset schedule wake 01/02/99 07:00:00 backup"},
+		{[]string{"schedule", "cancel", "0"},
+			"sudo pmset schedule cancel wake 10/17/26 06:00:00 com.apple.alarm.user-visible-Weekly Backup"},
+		{[]string{"schedule", "repeat", "sleep", "weekdays", "23:00"},
+			"sudo pmset repeat wakeorpoweron MTWRF 07:30:00 sleep MTWRF 23:00:00"},
+		{[]string{"schedule", "repeat", "cancel"}, "sudo pmset repeat cancel"},
+		{[]string{"schedule", "repeat", "sleep", "someday", "23:00"}, ""},
+		{[]string{"schedule", "add", "wake", "2001-01-01 07:00"}, ""},
+	}
+
+	for _, tt := range tests {
+		_, replay := runCLI(t, "macbook", tt.args...)
+		var want []string
+		if tt.want != "" {
+			want = []string{tt.want}
+		}
+		if !reflect.DeepEqual(replay.Calls, want) {
+			t.Errorf("macpwr %v ran %q, want %q", tt.args, replay.Calls, want)
+		}
+	}
+}
+
+func TestHibernateConfirmsRiskyMode(t *testing.T) {
+	display.DisableColor()
+	defer func(r io.Reader) { stdin = r }(stdin)
+
+	stdin = strings.NewReader("n\n")
+	out, replay := runCLI(t, "macbook", "hibernate", "--mode", "0")
+	if len(replay.Calls) != 0 {
+		t.Errorf("declined change ran %q", replay.Calls)
+	}
+	if !strings.Contains(out, "unsaved work is lost") {
+		t.Errorf("no warning for hibernatemode 0 on a laptop:\n%s", out)
+	}
+
+	stdin = strings.NewReader("")
+	_, replay = runCLI(t, "macbook", "hibernate", "--mode", "0", "--yes")
+	want := []string{"sudo pmset -a hibernatemode 0"}
+	if !reflect.DeepEqual(replay.Calls, want) {
+		t.Errorf("Calls = %q, want %q", replay.Calls, want)
+	}
+
+	_, replay = runCLI(t, "macbook", "hibernate", "-b", "--mode", "25")
+	want = []string{"sudo pmset -b hibernatemode 25"}
+	if !reflect.DeepEqual(replay.Calls, want) {
+		t.Errorf("Calls = %q, want %q", replay.Calls, want)
+	}
+
+	// A change that is rejected is not offered for confirmation
+	stdin = strings.NewReader("y\n")
+	out, replay = runCLI(t, "macbook", "hibernate", "-b", "--mode", "0", "--autopoweroff", "1")
+	if len(replay.Calls) != 0 || strings.Contains(out, "Continue?") {
+		t.Errorf("invalid change was confirmed:\n%s%q", out, replay.Calls)
+	}
+}
+
+func TestUPSThresholds(t *testing.T) {
+	display.DisableColor()
+
+	_, replay := runCLI(t, "imac-ups", "ups", "--level", "30", "--after", "0")
+	want := []string{"sudo pmset -u haltlevel 30"}
+	if !reflect.DeepEqual(replay.Calls, want) {
+		t.Errorf("Calls = %q, want %q", replay.Calls, want)
+	}
+
+	_, replay = runCLI(t, "imac-ups", "ups", "--level", "150")
+	if len(replay.Calls) != 0 {
+		t.Errorf("invalid haltlevel ran %q", replay.Calls)
+	}
+
+	_, replay = runCLI(t, "macbook", "ups", "--remain", "5")
+	if len(replay.Calls) != 0 {
+		t.Errorf("ups without a UPS ran %q", replay.Calls)
+	}
+}
+
+func TestUnsupportedSettingRejected(t *testing.T) {
+	display.DisableColor()
+
+	_, replay := runCLI(t, "macbook", "hibernate", "-b", "--autopoweroff", "1")
+	if len(replay.Calls) != 0 {
+		t.Errorf("unsupported setting ran %q", replay.Calls)
+	}
+
+	_, replay = runCLI(t, "macbook", "hibernate", "-b", "--standby", "0")
+	want := []string{"sudo pmset -b standby 0"}
+	if !reflect.DeepEqual(replay.Calls, want) {
+		t.Errorf("Calls = %q, want %q", replay.Calls, want)
+	}
+}
+
+func TestSetMissingSource(t *testing.T) {
+	display.DisableColor()
+
+	// This MacBook's pmset -g custom has no UPS Power section
+	for _, args := range [][]string{
+		{"set", "-u", "-d", "5"},
+		{"hibernate", "-u", "--standby", "0"},
+		{"lowpower", "on", "-u"},
+	} {
+		_, replay := runCLI(t, "macbook", args...)
+		if len(replay.Calls) != 0 {
+			t.Errorf("%q without a UPS ran %q", args, replay.Calls)
+		}
+	}
+}
+
+func TestSetWithoutSettings(t *testing.T) {
+	display.DisableColor()
+
+	// No fixtures, so pmset -g custom fails and nothing may be planned
+	out, replay := runCLI(t, "missing", "--dry-run", "set", "--all", "-d", "5")
+	if len(replay.Calls) != 0 || strings.Contains(out, "pmset") {
+		t.Errorf("set without readable settings planned:\n%s%q", out, replay.Calls)
+	}
+}
+
+func TestExportWritesScript(t *testing.T) {
+	display.DisableColor()
+
+	path := filepath.Join(t.TempDir(), "power.sh")
+	runCLI(t, "macbook", "export", "battery-saver", "-o", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "#!/bin/sh\n") {
+		t.Errorf("script has no shebang:\n%s", data)
+	}
+	if !strings.Contains(string(data), `apply -b "Battery Power" displaysleep 1`) {
+		t.Errorf("script does not set battery displaysleep:\n%s", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode()&0100 == 0 {
+		t.Errorf("script is not executable: %v", err)
+	}
+}
+
+func TestLowPower(t *testing.T) {
+	display.DisableColor()
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"lowpower", "on"}, []string{"sudo pmset -a lowpowermode 1"}},
+		{[]string{"lowpower", "on", "--battery"}, []string{"sudo pmset -b lowpowermode 1"}},
+		{[]string{"lowpower", "auto"}, []string{"sudo pmset -b lowpowermode 1"}},
+		{[]string{"lowpower", "off"}, nil},
+		{[]string{"lowpower", "auto", "--ac"}, nil},
+		{[]string{"lowpower", "sometimes"}, nil},
+	}
+
+	for _, tt := range tests {
+		_, replay := runCLI(t, "macbook", tt.args...)
+		if !reflect.DeepEqual(replay.Calls, tt.want) {
+			t.Errorf("%v: Calls = %q, want %q", tt.args, replay.Calls, tt.want)
+		}
+	}
+}
+
+func TestBatteryLog(t *testing.T) {
+	display.DisableColor()
+	t.Setenv("HOME", t.TempDir())
+
+	runCLI(t, "macbook", "battery", "log", "--once")
+	samples, err := batterylog.List()
+	if err != nil || len(samples) != 1 {
+		t.Fatalf("List() = %v, %v, want one sample", samples, err)
+	}
+	if s := samples[0]; s.Charge != 87 || s.CycleCount != 213 || s.Watts != 18.39 {
+		t.Errorf("sample = %+v", s)
+	}
+
+	out, replay := runCLI(t, "macbook", "--dry-run", "battery", "log", "--install")
+	if len(replay.Calls) != 0 || !strings.Contains(out, "launchctl load -w "+batterylog.AgentPath()) {
+		t.Errorf("dry run ran %q:\n%s", replay.Calls, out)
+	}
+	if _, err := os.Stat(batterylog.AgentPath()); !os.IsNotExist(err) {
+		t.Errorf("dry run installed the agent: %v", err)
+	}
+
+	_, replay = runCLI(t, "macbook", "battery", "log", "--install", "-i", "10m")
+	want := []string{"launchctl load -w " + batterylog.AgentPath()}
+	if !reflect.DeepEqual(replay.Calls, want) {
+		t.Errorf("Calls = %q, want %q", replay.Calls, want)
+	}
+	agent, err := os.ReadFile(batterylog.AgentPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"<string>--once</string>", "<integer>600</integer>"} {
+		if !strings.Contains(string(agent), s) {
+			t.Errorf("agent is missing %s:\n%s", s, agent)
+		}
+	}
+
+	_, replay = runCLI(t, "macbook", "--dry-run", "battery", "log", "--uninstall")
+	if _, err := os.Stat(batterylog.AgentPath()); len(replay.Calls) != 0 || err != nil {
+		t.Errorf("dry run uninstall ran %q, agent: %v", replay.Calls, err)
+	}
+
+	runCLI(t, "macbook", "battery", "log", "--uninstall")
+	if _, err := os.Stat(batterylog.AgentPath()); !os.IsNotExist(err) {
+		t.Errorf("agent still installed: %v", err)
+	}
+
+	// Intervals a ticker or launchd can't use are rejected
+	for _, args := range [][]string{{"-i", "0"}, {"-i", "-5m"}, {"--install", "-i", "500ms"}} {
+		_, replay := runCLI(t, "macbook", append([]string{"battery", "log"}, args...)...)
+		if len(replay.Calls) != 0 {
+			t.Errorf("battery log %q ran %q", args, replay.Calls)
+		}
+		if _, err := os.Stat(batterylog.AgentPath()); !os.IsNotExist(err) {
+			t.Errorf("battery log %q installed the agent", args)
+		}
+	}
+	if samples, _ := batterylog.List(); len(samples) != 1 {
+		t.Errorf("invalid intervals recorded samples: %d", len(samples))
+	}
+}
+
+func TestBatteryHistory(t *testing.T) {
+	display.DisableColor()
+	t.Setenv("HOME", t.TempDir())
+
+	start := time.Date(2026, 10, 10, 0, 0, 0, 0, time.Local)
+	for i := 0; i < 18; i++ {
+		s := batterylog.Sample{
+			Time:           start.Add(time.Duration(i) * 4 * time.Hour),
+			Charge:         100 - (i%6)*15,
+			Capacity:       3900 - (i%6)*580,
+			MaxCapacity:    3905 - i*2,
+			DesignCapacity: 4382,
+			CycleCount:     210 + i/6,
+			Temperature:    29 + float64(i%3),
+			Watts:          -6.5,
+		}
+		if err := batterylog.Append(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	args := []string{"battery", "history", "--since", "2026-10-10", "--until", "2026-10-13"}
+	out, _ := runCLI(t, "macbook", args...)
+	checkGolden(t, "battery_history", out)
+
+	out, _ = runCLI(t, "macbook", append(args, "--csv")...)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 19 || !strings.HasPrefix(lines[0], "time,charge,capacity") {
+		t.Errorf("CSV has %d lines, header %q", len(lines), lines[0])
+	}
+}
+
+func TestBatteryForecast(t *testing.T) {
+	display.DisableColor()
+	t.Setenv("HOME", t.TempDir())
+
+	// Monthly readings for the past year, ahead of the fixture's 3905 mAh
+	// and 213 cycles
+	for i := 12; i >= 1; i-- {
+		r := forecast.Reading{
+			Time:           time.Now().AddDate(0, -i, 0),
+			MaxCapacity:    3905 + i*30,
+			DesignCapacity: 4382,
+			CycleCount:     213 - i*12,
+			Serial:         "F8Y1234567ABCDEFG",
+		}
+		if _, err := forecast.Record(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, _ := runCLI(t, "macbook", "battery", "forecast", "--json")
+	var e forecast.Estimate
+	if err := json.Unmarshal([]byte(out), &e); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if e.Readings != 13 || e.CycleCount != 213 || e.AssumesNew {
+		t.Errorf("estimate = %+v", e)
+	}
+	// Health falls 30 mAh, about 0.68%, every 12 cycles from 89.1%
+	if e.Cycles == nil || *e.Cycles < 370 || *e.Cycles > 380 {
+		t.Errorf("Cycles = %v, want about 375", e.Cycles)
+	}
+	if e.Date == nil || !e.Date.After(time.Now()) {
+		t.Errorf("Date = %v, want a future date", e.Date)
+	}
+
+	out, _ = runCLI(t, "macbook", "battery", "forecast")
+	for _, want := range []string{"Battery Forecast", "Readings:", "13 since", "Estimated Date:", "Estimated Cycles:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if readings, _ := forecast.List(); len(readings) != 13 {
+		t.Errorf("recorded %d readings, want 13 after running twice", len(readings))
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/journal"
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/settings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// dryRun is set by the global --dry-run flag
+var dryRun bool
+
+// applyPlan validates a plan, then prints it under --dry-run or executes it
+// and records the change in the journal. It reports true only when the plan
+// was applied.
+func applyPlan(p *plan.Plan, command, errPrefix string) bool {
+	if err := p.Validate(); err != nil {
+		reportInvalid(err)
+		return false
+	}
+	if dryRun {
+		printPlan(p)
+		return false
+	}
+
+	for _, inv := range p.Invocations() {
+		fmt.Printf("Setting %s...\n", inv.Label())
+	}
+	if err := p.Execute(); err != nil {
+		reportApplyError(errPrefix, err)
+		return false
+	}
+
+	if e := journal.FromPlan(command, p); len(e.Changes) > 0 {
+		if err := journal.Append(e); err != nil {
+			display.Warning("Could not record change in journal: " + err.Error())
+		}
+	}
+	return true
+}
+
+// commandLine reconstructs the invoked command for the journal
+func commandLine(cmd *cobra.Command, args []string) string {
+	parts := strings.Fields(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Value.Type() == "bool" {
+			parts = append(parts, "--"+f.Name)
+		} else {
+			parts = append(parts, "--"+f.Name+"="+f.Value.String())
+		}
+	})
+	return strings.Join(append(parts, args...), " ")
+}
+
+// reportInvalid prints every problem found while validating settings
+func reportInvalid(err error) {
+	var verr *settings.ValidationError
+	if !errors.As(err, &verr) {
+		display.Error(err.Error())
+		return
+	}
+
+	display.Error("Invalid settings, nothing was changed:")
+	for _, problem := range verr.Problems {
+		fmt.Fprintf(os.Stderr, "  • %s\n", problem)
+	}
+}
+
+// printPlan shows the commands a plan would run and how settings would change
+func printPlan(p *plan.Plan) {
+	fmt.Printf("%sDry run: no changes will be made%s\n", display.Yellow, display.Reset)
+
+	if p.Empty() {
+		fmt.Printf("\n  %sNothing to do%s\n\n", display.Dim, display.Reset)
+		return
+	}
+
+	display.Section("Commands")
+	for _, cmd := range p.Commands() {
+		fmt.Printf("  %s\n", strings.Join(cmd, " "))
+	}
+
+	display.Section("Changes")
+	for _, c := range p.Changes() {
+		k, ok := settings.LookupKey(c.Key)
+		after := strconv.Itoa(c.After)
+		before := "?"
+		if ok {
+			after = formatValue(k, c.After)
+		}
+		if c.Known {
+			before = strconv.Itoa(c.Before)
+			if ok {
+				before = formatValue(k, c.Before)
+			}
+		}
+
+		if c.Changed() {
+			fmt.Printf("  %-10s %-20s %s → %s%s%s\n", c.Source.Label(), c.Key, before, display.Bold, after, display.Reset)
+		} else {
+			fmt.Printf("  %s%-10s %-20s %s (unchanged)%s\n", display.Dim, c.Source.Label(), c.Key, after, display.Reset)
+		}
+	}
+	fmt.Println()
+}
+
+// reportApplyError prints a failed plan execution, listing any keys that were
+// rolled back to their previous values
+func reportApplyError(prefix string, err error) {
+	display.Error(prefix + err.Error())
+
+	var rb *plan.RollbackError
+	if !errors.As(err, &rb) || len(rb.RolledBack) == 0 {
+		return
+	}
+
+	if rb.RestoreErr != nil {
+		display.Warning("Rollback did not complete; these settings may need restoring by hand:")
+	} else {
+		display.Warning("Rolled back to the previous settings:")
+	}
+	for _, c := range rb.RolledBack {
+		fmt.Printf("  %-10s %-20s %d → %d\n", c.Source.Label(), c.Key, c.Before, c.After)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/runner"
+	"github.com/born1337/macpwr/internal/schedule"
+
+	"github.com/spf13/cobra"
+)
+
+func scheduleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "schedule",
+		Aliases: []string{"sched"},
+		Short:   "Manage scheduled wake, sleep and power events",
+		Long: `Manage one-off and repeating power events (pmset schedule and pmset repeat).
+
+Event types: wake, sleep, poweron, shutdown, wakeorpoweron
+
+Examples:
+  macpwr schedule                                   List scheduled events
+  macpwr schedule add wake "2026-10-20 07:00"       Wake once at a date and time
+  macpwr schedule add shutdown 23:30                Shut down at the next 23:30
+  macpwr schedule cancel 0                          Cancel event [0] from the list
+  macpwr schedule repeat wakeorpoweron weekdays 7:30
+  macpwr schedule repeat sleep MTWRFSU 23:00        Sleep every night
+  macpwr schedule repeat cancel                     Remove all repeating events`,
+		Run: func(cmd *cobra.Command, args []string) {
+			listSchedule()
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List scheduled and repeating events",
+		Run: func(cmd *cobra.Command, args []string) {
+			listSchedule()
+		},
+	})
+
+	var owner string
+	add := &cobra.Command{
+		Use:   "add <type> <when>",
+		Short: "Schedule a one-off event",
+		Long: `Schedule a one-off power event.
+
+<when> is "YYYY-MM-DD HH:MM[:SS]", pmset's "MM/DD/YY HH:MM:SS", or a time
+of day alone (HH:MM), meaning its next occurrence.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			typ, err := schedule.ParseEventType(args[0])
+			if err != nil {
+				display.Error(err.Error())
+				return
+			}
+			at, err := schedule.ParseTime(args[1], time.Now())
+			if err != nil {
+				display.Error(err.Error())
+				return
+			}
+
+			e := schedule.ScheduledEvent{Type: typ, Time: at, Owner: owner}
+			if runPmset(schedule.ScheduleArgs(e)) {
+				display.Success("Scheduled " + e.String())
+			}
+		},
+	}
+	add.Flags().StringVar(&owner, "owner", "", "Name to record as the event's owner")
+	cmd.AddCommand(add)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "cancel <n|all>",
+		Short: "Cancel a one-off event by its number in the list",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if args[0] == "all" {
+				if runPmset([]string{"schedule", "cancelall"}) {
+					display.Success("Cancelled all scheduled events")
+				}
+				return
+			}
+
+			sched, err := schedule.Get()
+			if err != nil {
+				display.Error("Failed to read schedule: " + err.Error())
+				return
+			}
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 0 || n >= len(sched.Events) {
+				display.Error(fmt.Sprintf("No scheduled event [%s]: see 'macpwr schedule list'", args[0]))
+				return
+			}
+
+			e := sched.Events[n]
+			if runPmset(schedule.CancelArgs(e)) {
+				display.Success("Cancelled " + e.String())
+			}
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "repeat <type> <days> <time> | repeat cancel",
+		Short: "Set or cancel a repeating event",
+		Long: `Set a weekly repeating power event, or cancel all repeating events.
+
+<days> is a set of pmset weekday letters (MTWRFSU), day names such as
+"mon,wed,fri", or one of weekdays, weekends and daily.
+
+pmset keeps one repeating power-on event (wake, poweron, wakeorpoweron) and
+one power-off event (sleep, shutdown); setting one replaces the existing
+event of the same kind and keeps the other.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 && args[0] == "cancel" {
+				return nil
+			}
+			return cobra.ExactArgs(3)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if args[0] == "cancel" {
+				if runPmset([]string{"repeat", "cancel"}) {
+					display.Success("Cancelled all repeating events")
+				}
+				return
+			}
+
+			typ, err := schedule.ParseEventType(args[0])
+			if err != nil {
+				display.Error(err.Error())
+				return
+			}
+			days, err := schedule.ParseDays(args[1])
+			if err != nil {
+				display.Error(err.Error())
+				return
+			}
+			at, err := schedule.ParseTimeOfDay(args[2])
+			if err != nil {
+				display.Error(err.Error())
+				return
+			}
+
+			sched, err := schedule.Get()
+			if err != nil {
+				display.Error("Failed to read schedule: " + err.Error())
+				return
+			}
+
+			e := schedule.ScheduledEvent{Type: typ, Time: at, Repeat: days}
+			if runPmset(schedule.RepeatArgs(sched.Repeating, e)) {
+				display.Success("Repeating " + e.String())
+			}
+		},
+	})
+
+	return cmd
+}
+
+func listSchedule() {
+	sched, err := schedule.Get()
+	if err != nil {
+		display.Error("Failed to read schedule: " + err.Error())
+		return
+	}
+
+	fmt.Printf("\n%sPower Schedule%s\n", display.Bold, display.Reset)
+
+	display.Section("Repeating Events")
+	if len(sched.Repeating) == 0 {
+		fmt.Printf("  %sNo repeating events%s\n", display.Dim, display.Reset)
+	}
+	for _, e := range sched.Repeating {
+		fmt.Printf("  %-18s %s  %s%s%s\n",
+			e.Type.Label(), e.Time.Format("15:04"),
+			display.Cyan, e.Repeat, display.Reset)
+	}
+
+	display.Section("Scheduled Events")
+	if len(sched.Events) == 0 {
+		fmt.Printf("  %sNo scheduled events%s\n", display.Dim, display.Reset)
+	}
+	for i, e := range sched.Events {
+		fmt.Printf("  %s[%d]%s %-18s %s  %s%s%s\n",
+			display.Cyan, i, display.Reset,
+			e.Type.Label(), e.Time.Format("Mon 2006-01-02 15:04"),
+			display.Dim, orDash(e.Owner), display.Reset)
+	}
+	fmt.Println()
+}
+
+// runPmset runs a single privileged pmset command, or prints it under
+// --dry-run. It reports true only when the command ran successfully.
+func runPmset(args []string) bool {
+	argv := append([]string{"sudo", "pmset"}, args...)
+	if dryRun {
+		fmt.Printf("%sDry run: no changes will be made%s\n", display.Yellow, display.Reset)
+		display.Section("Commands")
+		fmt.Printf("  %s\n\n", strings.Join(argv, " "))
+		return false
+	}
+
+	if err := runner.Default.Run(argv[0], argv[1:]...); err != nil {
+		display.Error("pmset failed: " + err.Error())
+		return false
+	}
+	return true
+}
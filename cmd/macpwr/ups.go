@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/powersource"
+	"github.com/born1337/macpwr/internal/settings"
+
+	"github.com/spf13/cobra"
+)
+
+// upsFlags maps ups command flags to pmset keys
+var upsFlags = []struct {
+	flag, key, usage string
+}{
+	{"level", "haltlevel", "Shut down when the UPS battery falls to this percentage (0 = off)"},
+	{"after", "haltafter", "Shut down after this many minutes on UPS power (0 = off)"},
+	{"remain", "haltremain", "Shut down when this many minutes of UPS power remain (0 = off)"},
+}
+
+func upsCmd() *cobra.Command {
+	values := make(map[string]*int)
+
+	cmd := &cobra.Command{
+		Use:   "ups",
+		Short: "Show UPS status and set shutdown thresholds",
+		Long: `Show the status of an attached UPS and the thresholds at which macOS shuts
+down while running on UPS power.
+
+Examples:
+  macpwr ups                    Show UPS status and thresholds
+  macpwr ups --level 20         Shut down when the UPS reaches 20%
+  macpwr ups --remain 5         Shut down with 5 minutes of UPS power left
+  macpwr ups --after 0          Do not shut down after a fixed time`,
+		Run: func(cmd *cobra.Command, args []string) {
+			current, err := settings.Get()
+			if err != nil {
+				display.Error("Failed to read power settings: " + err.Error())
+				return
+			}
+
+			changed := false
+			for _, f := range upsFlags {
+				changed = changed || cmd.Flags().Changed(f.flag)
+			}
+			if !changed {
+				showUPS(current)
+				return
+			}
+
+			if current.For(settings.UPS) == nil {
+				display.Error("No UPS power settings found: is a UPS connected?")
+				return
+			}
+
+			p := plan.New(current)
+			for _, f := range upsFlags {
+				if cmd.Flags().Changed(f.flag) {
+					p.Set(settings.UPS, f.key, *values[f.flag])
+				}
+			}
+			if !applyPlan(p, commandLine(cmd, args), "Failed to apply UPS settings: ") {
+				return
+			}
+			display.Success("UPS shutdown thresholds applied")
+		},
+	}
+
+	for _, f := range upsFlags {
+		values[f.flag] = cmd.Flags().Int(f.flag, 0, f.usage)
+	}
+
+	return cmd
+}
+
+func showUPS(s *settings.AllSettings) {
+	display.Header("UPS")
+
+	display.Section("Status")
+	var ups *powersource.Source
+	ps, err := powersource.Get()
+	if err == nil {
+		ups = ps.UPS()
+	}
+	if ups == nil {
+		fmt.Printf("  %sNo UPS detected%s\n", display.Dim, display.Reset)
+	} else {
+		display.KV("Device", ups.Name)
+		display.KV("Charge", display.FormatPercent(ups.Percent))
+		display.KV("State", stateColor(ups.State)+ups.State.Label()+display.Reset)
+		display.KV("Time", formatRemaining(ups))
+		if ps.OnUPS() {
+			display.KV("Power Source", display.Red+"Running on UPS"+display.Reset)
+		} else {
+			display.KV("Power Source", display.Green+ps.Label()+display.Reset)
+		}
+	}
+
+	display.Section("Shutdown Thresholds")
+	u := s.For(settings.UPS)
+	if u == nil {
+		fmt.Printf("  %sNo UPS power settings (pmset reports no UPS Power section)%s\n", display.Dim, display.Reset)
+		fmt.Println()
+		return
+	}
+	for _, k := range settings.KeysFor(settings.UPS) {
+		val, _ := u.Value(k.Name)
+		switch {
+		case !s.Caps.Supports(settings.UPS, k.Name):
+			display.KV(k.Label, display.Dim+"n/a"+display.Reset)
+		case !u.Has(k.Name):
+			display.KV(k.Label, "—")
+		case val == 0:
+			display.KV(k.Label, display.Dim+"Off"+display.Reset)
+		default:
+			display.KV(k.Label, formatValue(k, val))
+		}
+	}
+	fmt.Println()
+}
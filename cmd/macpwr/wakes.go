@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/display"
+	"github.com/born1337/macpwr/internal/powerlog"
+
+	"github.com/spf13/cobra"
+)
+
+func wakesCmd() *cobra.Command {
+	var since, until string
+
+	cmd := &cobra.Command{
+		Use:   "wakes",
+		Short: "Rank the reasons this Mac woke from sleep",
+		Long: `Aggregate wake reasons from 'pmset -g log' and rank the causes of
+unexpected wakes, such as network, USB and Bluetooth devices or RTC timers.
+Wakes from opening the lid, pressing a key or button, or a scheduled event
+are counted as expected.
+
+Examples:
+  macpwr wakes                  Wakes from the last 7 days
+  macpwr wakes --since 24h      Wakes from the last day`,
+		Run: func(cmd *cobra.Command, args []string) {
+			filter, err := logFilter(since, until, nil)
+			if err != nil {
+				display.Error(err.Error())
+				return
+			}
+
+			events, err := powerlog.Get()
+			if err != nil {
+				display.Error("Failed to read power log: " + err.Error())
+				return
+			}
+			r := powerlog.AnalyzeWakes(filter.Apply(events))
+
+			fmt.Printf("\n%sWake Reasons%s  %ssince %s%s\n",
+				display.Bold, display.Reset,
+				display.Dim, filter.Since.Format("2006-01-02 15:04"), display.Reset)
+
+			display.Section("Summary")
+			display.KV("Wakes", fmt.Sprintf("%d", r.Wakes))
+			display.KV("Dark Wakes", fmt.Sprintf("%d", r.DarkWakes))
+			display.KV("Power Nap Dark Wakes", fmt.Sprintf("%d", r.PowerNap))
+			unexpected := fmt.Sprintf("%d", r.UnexpectedTotal())
+			if r.UnexpectedTotal() > 0 {
+				unexpected = display.Yellow + unexpected + display.Reset
+			}
+			display.KV("Unexpected", unexpected)
+
+			display.Section("Top Unexpected Causes")
+			if len(r.Unexpected()) == 0 {
+				fmt.Printf("  %sNo unexpected wakes%s\n", display.Green, display.Reset)
+			}
+			for i, c := range r.Unexpected() {
+				printCause(i+1, c)
+			}
+
+			var expected []powerlog.CauseCount
+			for _, c := range r.Causes {
+				if c.Cause.Expected() {
+					expected = append(expected, c)
+				}
+			}
+			if len(expected) > 0 {
+				display.Section("Expected Wakes")
+				for i, c := range expected {
+					printCause(i+1, c)
+				}
+			}
+			fmt.Println()
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "7d", "Count wakes after this time")
+	cmd.Flags().StringVar(&until, "until", "", "Count wakes before this time")
+
+	return cmd
+}
+
+func printCause(rank int, c powerlog.CauseCount) {
+	fmt.Printf("  %s%d.%s %-15s %s%3d%s  %s%d full, %d dark%s\n",
+		display.Cyan, rank, display.Reset,
+		c.Cause, display.Bold, c.Total(), display.Reset,
+		display.Dim, c.Wakes, c.DarkWakes, display.Reset)
+	if len(c.Reasons) > 0 {
+		fmt.Printf("     %s%s%s\n", display.Dim, strings.Join(c.Reasons, ", "), display.Reset)
+	}
+}
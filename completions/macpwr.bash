@@ -2,15 +2,17 @@
 # Add to ~/.bashrc: source /path/to/macpwr/completions/macpwr.bash
 
 _macpwr_completions() {
-    local cur prev commands presets profile_cmds
+    local cur prev commands presets profile_cmds schedule_cmds event_types
 
     COMPREPLY=()
     cur="${COMP_WORDS[COMP_CWORD]}"
     prev="${COMP_WORDS[COMP_CWORD-1]}"
 
-    commands="status show set battery preset profile caffeinate assertions thermal help version"
+    commands="status show set battery charger preset profile diff export history undo enforce schedule hibernate log wakes ups lowpower caffeinate assertions thermal help version"
     presets="default presentation battery-saver performance movie list"
     profile_cmds="save load list delete"
+    schedule_cmds="list add cancel repeat"
+    event_types="wake sleep poweron shutdown wakeorpoweron"
 
     case "$prev" in
         macpwr)
@@ -25,8 +27,20 @@ _macpwr_completions() {
             COMPREPLY=($(compgen -W "$profile_cmds" -- "$cur"))
             return 0
             ;;
+        schedule|sched)
+            COMPREPLY=($(compgen -W "$schedule_cmds" -- "$cur"))
+            return 0
+            ;;
+        add)
+            COMPREPLY=($(compgen -W "$event_types" -- "$cur"))
+            return 0
+            ;;
+        repeat)
+            COMPREPLY=($(compgen -W "$event_types cancel" -- "$cur"))
+            return 0
+            ;;
         set)
-            COMPREPLY=($(compgen -W "-a --ac -b --battery -d --display -s --sleep -k --disk" -- "$cur"))
+            COMPREPLY=($(compgen -W "-a --ac -b --battery -u --ups --all -d --display -s --sleep -k --disk" -- "$cur"))
             return 0
             ;;
         caffeinate|cafe)
@@ -37,7 +51,7 @@ _macpwr_completions() {
             COMPREPLY=($(compgen -W "$commands" -- "$cur"))
             return 0
             ;;
-        load|delete)
+        load|delete|enforce)
             # Complete with saved profile names
             local profiles_dir="$HOME/.config/macpwr/profiles"
             if [[ -d "$profiles_dir" ]]; then
@@ -46,11 +60,23 @@ _macpwr_completions() {
             fi
             return 0
             ;;
-        -a|--ac|-b|--battery)
+        -a|--ac|-b|--battery|-u|--ups)
             COMPREPLY=($(compgen -W "-d --display -s --sleep -k --disk" -- "$cur"))
             return 0
             ;;
-        -d|--display|-s|--sleep|-k|--disk|-t|--time)
+        -t|--type)
+            if [[ "${COMP_WORDS[1]}" == "log" ]]; then
+                COMPREPLY=($(compgen -W "sleep wake darkwake assertion" -- "$cur"))
+            fi
+            return 0
+            ;;
+        -f|--format)
+            if [[ "${COMP_WORDS[1]}" == "export" ]]; then
+                COMPREPLY=($(compgen -W "sh" -- "$cur"))
+            fi
+            return 0
+            ;;
+        -d|--display|-s|--sleep|-k|--disk|--time)
             # Expect a number
             COMPREPLY=()
             return 0
@@ -61,11 +87,48 @@ _macpwr_completions() {
     if [[ ${COMP_CWORD} -gt 1 ]]; then
         case "${COMP_WORDS[1]}" in
             set)
-                COMPREPLY=($(compgen -W "-a --ac -b --battery -d --display -s --sleep -k --disk" -- "$cur"))
+                COMPREPLY=($(compgen -W "-a --ac -b --battery -u --ups --all -d --display -s --sleep -k --disk" -- "$cur"))
                 ;;
             caffeinate|cafe)
                 COMPREPLY=($(compgen -W "-t --time -d --display -i --idle -s --system" -- "$cur"))
                 ;;
+            enforce)
+                COMPREPLY=($(compgen -W "-i --interval --check --fix" -- "$cur"))
+                ;;
+            log)
+                COMPREPLY=($(compgen -W "--since --until -t --type" -- "$cur"))
+                ;;
+            wakes)
+                COMPREPLY=($(compgen -W "--since --until" -- "$cur"))
+                ;;
+            ups)
+                COMPREPLY=($(compgen -W "--level --after --remain" -- "$cur"))
+                ;;
+            battery|batt)
+                case "${COMP_WORDS[2]}" in
+                    log)
+                        COMPREPLY=($(compgen -W "-i --interval --once --install --uninstall" -- "$cur"))
+                        ;;
+                    history)
+                        COMPREPLY=($(compgen -W "--since --until --csv --json" -- "$cur"))
+                        ;;
+                    forecast)
+                        COMPREPLY=($(compgen -W "--json" -- "$cur"))
+                        ;;
+                    *)
+                        COMPREPLY=($(compgen -W "log history forecast --raw" -- "$cur"))
+                        ;;
+                esac
+                ;;
+            lowpower|lpm)
+                COMPREPLY=($(compgen -W "on off auto -a --ac -b --battery -u --ups --all" -- "$cur"))
+                ;;
+            export)
+                COMPREPLY=($(compgen -W "-f --format -o --output $presets" -- "$cur"))
+                ;;
+            hibernate)
+                COMPREPLY=($(compgen -W "-a --ac -b --battery -u --ups --all -y --yes --mode --standby --delay-low --delay-high --threshold --autopoweroff --autopoweroff-delay" -- "$cur"))
+                ;;
         esac
     fi
 
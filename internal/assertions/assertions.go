@@ -1,10 +1,12 @@
 package assertions
 
 import (
-	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/born1337/macpwr/internal/runner"
+	"github.com/born1337/macpwr/internal/schedule"
 )
 
 // Summary contains assertion counts
@@ -24,22 +26,16 @@ type Assertion struct {
 	Type    string
 }
 
-// ScheduledEvent represents a scheduled wake/sleep event
-type ScheduledEvent struct {
-	Description string
-}
-
 // Info contains all assertion information
 type Info struct {
 	Summary   Summary
 	Active    []Assertion
-	Scheduled []ScheduledEvent
+	Scheduled []schedule.ScheduledEvent
 }
 
 // Get retrieves power assertions
 func Get() (*Info, error) {
-	cmd := exec.Command("pmset", "-g", "assertions")
-	output, err := cmd.Output()
+	output, err := runner.Default.Output("pmset", "-g", "assertions")
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +57,9 @@ func Get() (*Info, error) {
 	}
 
 	// Get scheduled events
-	info.Scheduled = getScheduledEvents()
+	if sched, err := schedule.Get(); err == nil {
+		info.Scheduled = sched.All()
+	}
 
 	return info, nil
 }
@@ -105,26 +103,3 @@ func parseAssertions(data string) []Assertion {
 
 	return assertions
 }
-
-func getScheduledEvents() []ScheduledEvent {
-	cmd := exec.Command("pmset", "-g", "sched")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil
-	}
-
-	data := strings.TrimSpace(string(output))
-	if strings.Contains(data, "No scheduled") || len(data) == 0 {
-		return nil
-	}
-
-	var events []ScheduledEvent
-	for _, line := range strings.Split(data, "\n") {
-		line = strings.TrimSpace(line)
-		if len(line) > 0 && !strings.HasPrefix(line, "Scheduled") {
-			events = append(events, ScheduledEvent{Description: line})
-		}
-	}
-
-	return events
-}
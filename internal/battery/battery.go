@@ -1,13 +1,16 @@
 package battery
 
 import (
-	"os/exec"
-	"regexp"
+	"bytes"
+	"fmt"
 	"strconv"
-	"strings"
+	"time"
+
+	"github.com/born1337/macpwr/internal/plist"
+	"github.com/born1337/macpwr/internal/runner"
 )
 
-// Info contains battery information
+// Info is the AppleSmartBattery entry in the IOKit registry
 type Info struct {
 	CurrentCapacity    int
 	MaxCapacity        int
@@ -20,40 +23,231 @@ type Info struct {
 	TimeRemaining      int // in minutes
 	RawCurrentCapacity int // AppleRawCurrentCapacity for Apple Silicon
 	RawMaxCapacity     int // AppleRawMaxCapacity for Apple Silicon
+
+	Voltage         int    // in mV
+	Amperage        int    // in mA, negative while discharging
+	InstantAmperage int    // in mA, negative while discharging
+	Serial          string // battery serial number
+	DeviceName      string // gas gauge chip, such as "bq40z651"
+	ManufactureDate int    // packed as ((year-1980)<<9) | (month<<5) | day
+
+	BatteryData    BatteryData
+	ChargerData    ChargerData
+	AdapterDetails AdapterDetails
+	Telemetry      PowerTelemetry
+
+	Raw plist.Dict // every key ioreg reported
+}
+
+// BatteryData is the gas gauge's own view of the battery pack
+type BatteryData struct {
+	StateOfCharge  int // in percent
+	Voltage        int // in mV
+	CycleCount     int
+	DesignCapacity int   // in mAh
+	CellVoltage    []int // in mV, per cell
+	Qmax           []int // in mAh, per cell
+	Lifetime       Lifetime
+}
+
+// Lifetime holds the extremes the gas gauge has recorded over the pack's life
+type Lifetime struct {
+	MaximumTemperature      int // in °C
+	MinimumTemperature      int // in °C
+	AverageTemperature      int // in °C
+	MaximumPackVoltage      int // in mV
+	MinimumPackVoltage      int // in mV
+	MaximumChargeCurrent    int // in mA
+	MaximumDischargeCurrent int // in mA, negative
+	TotalOperatingTime      int // in hours
+}
+
+// ChargerData is the state of the Mac's charging circuit
+type ChargerData struct {
+	ChargerID            int
+	ChargingCurrent      int // in mA
+	ChargingVoltage      int // in mV
+	NotChargingReason    int // bit field, 0 when charging is allowed
+	ChargerInhibitReason int
+	SlowChargingReason   int
+}
+
+// PowerTelemetry is the power flow Apple Silicon Macs measure themselves.
+// Intel Macs leave it empty.
+type PowerTelemetry struct {
+	SystemLoad      int // power the Mac is using, in mW
+	SystemPowerIn   int // power coming in from the adapter, in mW
+	BatteryPower    int // power into the battery, in mW
+	SystemVoltageIn int // in mV
+	SystemCurrentIn int // in mA
 }
 
-// GetInfo retrieves battery information from IOKit
+// AdapterDetails describes the connected power adapter
+type AdapterDetails struct {
+	Name             string // "67W USB-C Power Adapter"
+	Manufacturer     string
+	Description      string // "pd charger" for USB Power Delivery
+	Watts            int    // rated watts
+	AdapterVoltage   int    // negotiated voltage, in mV
+	Current          int    // negotiated current, in mA
+	FamilyCode       int64
+	SerialString     string
+	AdapterID        int
+	IsWireless       bool
+	PMUConfiguration int // input current limit, in mA
+	UsbHvcHvcIndex   int // index of the negotiated option in UsbHvcMenu
+	UsbHvcMenu       []UsbHvcOption
+}
+
+// UsbHvcOption is one voltage and current pair a USB-PD adapter offers
+type UsbHvcOption struct {
+	Index      int
+	MaxVoltage int // in mV
+	MaxCurrent int // in mA
+}
+
+// Connected reports whether ioreg described an adapter
+func (a *AdapterDetails) Connected() bool {
+	return a.Watts > 0 || a.Name != ""
+}
+
+// GetInfo retrieves battery information from IOKit. It returns nil if the
+// Mac has no battery.
 func GetInfo() (*Info, error) {
-	cmd := exec.Command("ioreg", "-rc", "AppleSmartBattery")
-	output, err := cmd.Output()
+	output, err := runner.Default.Output("ioreg", "-arc", "AppleSmartBattery")
 	if err != nil {
 		return nil, err
 	}
-
-	data := string(output)
-	if len(data) == 0 {
+	if len(bytes.TrimSpace(output)) == 0 {
 		return nil, nil // No battery
 	}
+	return parse(output)
+}
+
+// parse decodes the plist written by ioreg -a, an array with one dictionary
+// per matching registry entry
+func parse(data []byte) (*Info, error) {
+	v, err := plist.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	entries, _ := v.([]any)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	d, ok := entries[0].(plist.Dict)
+	if !ok {
+		return nil, fmt.Errorf("unexpected ioreg output: %T", entries[0])
+	}
+
+	info := &Info{
+		CurrentCapacity:    d.Int("CurrentCapacity"),
+		MaxCapacity:        d.Int("MaxCapacity"),
+		DesignCapacity:     d.Int("DesignCapacity"),
+		CycleCount:         d.Int("CycleCount"),
+		IsCharging:         d.Bool("IsCharging"),
+		ExternalConnected:  d.Bool("ExternalConnected"),
+		FullyCharged:       d.Bool("FullyCharged"),
+		Temperature:        d.Int("Temperature"),
+		TimeRemaining:      d.Int("TimeRemaining"),
+		RawCurrentCapacity: d.Int("AppleRawCurrentCapacity"),
+		RawMaxCapacity:     d.Int("AppleRawMaxCapacity"),
+		Voltage:            d.Int("Voltage"),
+		Amperage:           d.Int("Amperage"),
+		InstantAmperage:    d.Int("InstantAmperage"),
+		Serial:             d.String("Serial"),
+		DeviceName:         d.String("DeviceName"),
+		ManufactureDate:    d.Int("ManufactureDate"),
+		Raw:                d,
+	}
+
+	if bd := d.Dict("BatteryData"); bd != nil {
+		lt := bd.Dict("LifetimeData")
+		info.BatteryData = BatteryData{
+			StateOfCharge:  bd.Int("StateOfCharge"),
+			Voltage:        bd.Int("Voltage"),
+			CycleCount:     bd.Int("CycleCount"),
+			DesignCapacity: bd.Int("DesignCapacity"),
+			CellVoltage:    bd.Ints("CellVoltage"),
+			Qmax:           bd.Ints("Qmax"),
+			Lifetime: Lifetime{
+				MaximumTemperature:      lt.Int("MaximumTemperature"),
+				MinimumTemperature:      lt.Int("MinimumTemperature"),
+				AverageTemperature:      lt.Int("AverageTemperature"),
+				MaximumPackVoltage:      lt.Int("MaximumPackVoltage"),
+				MinimumPackVoltage:      lt.Int("MinimumPackVoltage"),
+				MaximumChargeCurrent:    lt.Int("MaximumChargeCurrent"),
+				MaximumDischargeCurrent: lt.Int("MaximumDischargeCurrent"),
+				TotalOperatingTime:      lt.Int("TotalOperatingTime"),
+			},
+		}
+	}
 
-	info := &Info{}
+	if cd := d.Dict("ChargerData"); cd != nil {
+		info.ChargerData = ChargerData{
+			ChargerID:            cd.Int("ChargerID"),
+			ChargingCurrent:      cd.Int("ChargingCurrent"),
+			ChargingVoltage:      cd.Int("ChargingVoltage"),
+			NotChargingReason:    cd.Int("NotChargingReason"),
+			ChargerInhibitReason: cd.Int("ChargerInhibitReason"),
+			SlowChargingReason:   cd.Int("SlowChargingReason"),
+		}
+	}
 
-	// Parse values using regex for top-level keys
-	info.CurrentCapacity = parseIntValue(data, "CurrentCapacity")
-	info.MaxCapacity = parseIntValue(data, "MaxCapacity")
-	info.DesignCapacity = parseIntValue(data, "DesignCapacity")
-	info.CycleCount = parseIntValue(data, "CycleCount")
-	info.Temperature = parseIntValue(data, "Temperature")
-	info.TimeRemaining = parseIntValue(data, "TimeRemaining")
-	info.RawCurrentCapacity = parseIntValue(data, "AppleRawCurrentCapacity")
-	info.RawMaxCapacity = parseIntValue(data, "AppleRawMaxCapacity")
+	if pt := d.Dict("PowerTelemetryData"); pt != nil {
+		info.Telemetry = PowerTelemetry{
+			SystemLoad:      pt.Int("SystemLoad"),
+			SystemPowerIn:   pt.Int("SystemPowerIn"),
+			BatteryPower:    pt.Int("BatteryPower"),
+			SystemVoltageIn: pt.Int("SystemVoltageIn"),
+			SystemCurrentIn: pt.Int("SystemCurrentIn"),
+		}
+	}
 
-	info.IsCharging = parseBoolValue(data, "IsCharging")
-	info.ExternalConnected = parseBoolValue(data, "ExternalConnected")
-	info.FullyCharged = parseBoolValue(data, "FullyCharged")
+	if ad := d.Dict("AdapterDetails"); ad != nil {
+		a := AdapterDetails{
+			Name:             ad.String("Name"),
+			Manufacturer:     ad.String("Manufacturer"),
+			Description:      ad.String("Description"),
+			Watts:            ad.Int("Watts"),
+			AdapterVoltage:   ad.Int("AdapterVoltage"),
+			Current:          ad.Int("Current"),
+			FamilyCode:       ad.Int64("FamilyCode"),
+			SerialString:     ad.String("SerialString"),
+			AdapterID:        ad.Int("AdapterID"),
+			IsWireless:       ad.Bool("IsWireless"),
+			PMUConfiguration: ad.Int("PMUConfiguration"),
+			UsbHvcHvcIndex:   ad.Int("UsbHvcHvcIndex"),
+		}
+		for _, v := range ad.Array("UsbHvcMenu") {
+			if opt, ok := v.(plist.Dict); ok {
+				a.UsbHvcMenu = append(a.UsbHvcMenu, UsbHvcOption{
+					Index:      opt.Int("Index"),
+					MaxVoltage: opt.Int("MaxVoltage"),
+					MaxCurrent: opt.Int("MaxCurrent"),
+				})
+			}
+		}
+		info.AdapterDetails = a
+	}
 
 	return info, nil
 }
 
+// Manufactured decodes ManufactureDate, reporting false if it is unset
+func (i *Info) Manufactured() (time.Time, bool) {
+	if i.ManufactureDate <= 0 {
+		return time.Time{}, false
+	}
+	year := 1980 + i.ManufactureDate>>9
+	month := time.Month((i.ManufactureDate >> 5) & 0xF)
+	day := i.ManufactureDate & 0x1F
+	if month < 1 || month > 12 || day < 1 {
+		return time.Time{}, false
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), true
+}
+
 // ActualCurrentCapacity returns the actual current capacity (prefers raw values)
 func (i *Info) ActualCurrentCapacity() int {
 	if i.RawCurrentCapacity > 0 {
@@ -129,23 +323,3 @@ func (i *Info) TimeRemainingFormatted() string {
 func formatDuration(hours, mins int) string {
 	return strconv.Itoa(hours) + "h " + strconv.Itoa(mins) + "m"
 }
-
-func parseIntValue(data, key string) int {
-	// Match lines like:   "Key" = value (with leading whitespace)
-	re := regexp.MustCompile(`(?m)^\s+"` + key + `"\s*=\s*(\d+)`)
-	matches := re.FindStringSubmatch(data)
-	if len(matches) >= 2 {
-		val, _ := strconv.Atoi(matches[1])
-		return val
-	}
-	return 0
-}
-
-func parseBoolValue(data, key string) bool {
-	re := regexp.MustCompile(`(?m)^\s+"` + key + `"\s*=\s*(\w+)`)
-	matches := re.FindStringSubmatch(data)
-	if len(matches) >= 2 {
-		return strings.ToLower(matches[1]) == "yes"
-	}
-	return false
-}
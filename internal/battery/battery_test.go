@@ -0,0 +1,185 @@
+package battery
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const discharging = `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<array>
+	<dict>
+		<key>AppleRawCurrentCapacity</key>
+		<integer>2100</integer>
+		<key>AppleRawMaxCapacity</key>
+		<integer>4200</integer>
+		<key>DesignCapacity</key>
+		<integer>4382</integer>
+		<key>Amperage</key>
+		<integer>18446744073709550391</integer>
+		<key>InstantAmperage</key>
+		<integer>18446744073709550400</integer>
+		<key>Voltage</key>
+		<integer>11820</integer>
+		<key>ExternalConnected</key>
+		<false/>
+		<key>ManufactureDate</key>
+		<integer>21052</integer>
+		<key>BatteryData</key>
+		<dict>
+			<key>Qmax</key>
+			<array>
+				<integer>4101</integer>
+				<integer>4097</integer>
+			</array>
+			<key>LifetimeData</key>
+			<dict>
+				<key>MaximumTemperature</key>
+				<integer>46</integer>
+			</dict>
+		</dict>
+		<key>ChargerData</key>
+		<dict>
+			<key>NotChargingReason</key>
+			<integer>4</integer>
+		</dict>
+		<key>AdapterDetails</key>
+		<dict>
+			<key>FamilyCode</key>
+			<integer>0</integer>
+		</dict>
+	</dict>
+</array>
+</plist>
+`
+
+func TestParse(t *testing.T) {
+	info, err := parse([]byte(discharging))
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+
+	if info.Amperage != -1225 || info.InstantAmperage != -1216 {
+		t.Errorf("Amperage = %d, InstantAmperage = %d, want -1225, -1216", info.Amperage, info.InstantAmperage)
+	}
+	if info.Voltage != 11820 {
+		t.Errorf("Voltage = %d, want 11820", info.Voltage)
+	}
+	if got := info.ChargePercent(); got != 50 {
+		t.Errorf("ChargePercent() = %d, want 50", got)
+	}
+	if got := info.BatteryData.Qmax; !reflect.DeepEqual(got, []int{4101, 4097}) {
+		t.Errorf("Qmax = %v", got)
+	}
+	if got := info.BatteryData.Lifetime.MaximumTemperature; got != 46 {
+		t.Errorf("MaximumTemperature = %d, want 46", got)
+	}
+	if info.ChargerData.NotChargingReason != 4 {
+		t.Errorf("NotChargingReason = %d, want 4", info.ChargerData.NotChargingReason)
+	}
+	if info.AdapterDetails.Connected() {
+		t.Errorf("AdapterDetails without name or watts reported as connected")
+	}
+	if _, ok := info.Raw["ManufactureDate"]; !ok {
+		t.Errorf("Raw is missing ManufactureDate")
+	}
+
+	made, ok := info.Manufactured()
+	if !ok || !made.Equal(time.Date(2021, 1, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Manufactured() = %v, %v, want 2021-01-28", made, ok)
+	}
+}
+
+func TestParseNoBattery(t *testing.T) {
+	info, err := parse([]byte(`<plist version="1.0"><array/></plist>`))
+	if err != nil || info != nil {
+		t.Errorf("parse(empty) = %v, %v, want nil, nil", info, err)
+	}
+}
+
+func TestDraw(t *testing.T) {
+	adapter := AdapterDetails{Name: "30W USB-C Power Adapter", Watts: 30, AdapterVoltage: 15000, Current: 2000}
+
+	tests := []struct {
+		name    string
+		info    Info
+		want    Draw
+		deficit bool
+		under   bool
+	}{
+		{
+			name: "telemetry",
+			info: Info{Voltage: 12843, Amperage: 1432, ExternalConnected: true, AdapterDetails: adapter,
+				Telemetry: PowerTelemetry{SystemLoad: 14210}},
+			want: Draw{Battery: 18.391176, Adapter: 30, System: 14.21, Measured: true},
+		},
+		{
+			name: "load above adapter",
+			info: Info{Voltage: 12843, Amperage: 0, ExternalConnected: true, AdapterDetails: adapter,
+				Telemetry: PowerTelemetry{SystemLoad: 41000}},
+			want:  Draw{Adapter: 30, System: 41, Measured: true},
+			under: true,
+		},
+		{
+			name: "on battery",
+			info: Info{Voltage: 12000, Amperage: -1000},
+			want: Draw{Battery: -12, System: 12},
+		},
+		{
+			name:    "draining on AC",
+			info:    Info{Voltage: 12000, Amperage: -500, ExternalConnected: true, AdapterDetails: adapter},
+			want:    Draw{Battery: -6, Adapter: 30, System: 36},
+			deficit: true,
+			under:   true,
+		},
+		{
+			name: "charging without telemetry",
+			info: Info{Voltage: 12000, Amperage: 1000, ExternalConnected: true, AdapterDetails: AdapterDetails{Watts: 96}},
+			want: Draw{Battery: 12, Adapter: 96},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.info.Draw()
+			if !closeTo(got.Battery, tt.want.Battery) || !closeTo(got.Adapter, tt.want.Adapter) ||
+				!closeTo(got.System, tt.want.System) || got.Measured != tt.want.Measured {
+				t.Errorf("Draw() = %+v, want %+v", got, tt.want)
+			}
+			if got := tt.info.Deficit(); got != tt.deficit {
+				t.Errorf("Deficit() = %v, want %v", got, tt.deficit)
+			}
+			if got := tt.info.Underpowered(); got != tt.under {
+				t.Errorf("Underpowered() = %v, want %v", got, tt.under)
+			}
+		})
+	}
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 0.001
+}
+
+func TestAdapterDetails(t *testing.T) {
+	a := AdapterDetails{
+		FamilyCode:     -536854520,
+		UsbHvcHvcIndex: 1,
+		UsbHvcMenu: []UsbHvcOption{
+			{Index: 0, MaxVoltage: 5000, MaxCurrent: 3000},
+			{Index: 1, MaxVoltage: 20000, MaxCurrent: 3350},
+		},
+	}
+
+	if got := a.Family(); got != "0xe0004008" {
+		t.Errorf("Family() = %q, want 0xe0004008", got)
+	}
+	opt, ok := a.Negotiated()
+	if !ok || opt.MaxVoltage != 20000 || !closeTo(opt.Watts(), 67) {
+		t.Errorf("Negotiated() = %+v, %v, want the 20 V option", opt, ok)
+	}
+	if got := (&AdapterDetails{}).Family(); got != "" {
+		t.Errorf("Family() without a code = %q", got)
+	}
+}
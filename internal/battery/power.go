@@ -0,0 +1,84 @@
+package battery
+
+import "fmt"
+
+// Draw is the power flowing through the battery and adapter, in watts
+type Draw struct {
+	Battery float64 // into the battery while charging, negative while discharging
+	Adapter float64 // input the adapter negotiated, 0 without an adapter
+	System  float64 // power the Mac is using, 0 if it cannot be worked out
+
+	// Measured is true when System comes from the Mac's own telemetry
+	// rather than being computed from the battery and adapter
+	Measured bool
+}
+
+// Draw works out the current power flow. The battery rate is voltage ×
+// amperage. System power comes from PowerTelemetryData where the Mac reports
+// it; otherwise it is the battery's discharge rate on battery power, or the
+// adapter input plus the discharge rate when the battery drains on AC.
+func (i *Info) Draw() Draw {
+	d := Draw{
+		Battery: float64(i.Voltage) * float64(i.Amperage) / 1e6,
+		Adapter: i.AdapterDetails.InputWatts(),
+	}
+
+	switch {
+	case i.Telemetry.SystemLoad > 0:
+		d.System = float64(i.Telemetry.SystemLoad) / 1000
+		d.Measured = true
+	case !i.ExternalConnected && d.Battery < 0:
+		d.System = -d.Battery
+	case d.Battery < 0 && d.Adapter > 0:
+		d.System = d.Adapter - d.Battery
+	}
+	return d
+}
+
+// Deficit reports whether the battery is draining even though an adapter is
+// connected, meaning the Mac uses more power than the adapter supplies
+func (i *Info) Deficit() bool {
+	return i.ExternalConnected && i.Amperage < 0
+}
+
+// InputWatts returns the power the adapter negotiated, falling back to its
+// rating when ioreg does not report the negotiated voltage and current
+func (a *AdapterDetails) InputWatts() float64 {
+	if a.AdapterVoltage > 0 && a.Current > 0 {
+		return float64(a.AdapterVoltage) * float64(a.Current) / 1e6
+	}
+	return float64(a.Watts)
+}
+
+// Underpowered reports whether the Mac uses more power than the adapter
+// supplies, either measured or shown by the battery draining on AC
+func (i *Info) Underpowered() bool {
+	if i.Deficit() {
+		return true
+	}
+	d := i.Draw()
+	return d.Adapter > 0 && d.System > d.Adapter
+}
+
+// Family returns the adapter family code as IOKit's 32-bit hex identifier
+func (a *AdapterDetails) Family() string {
+	if a.FamilyCode == 0 {
+		return ""
+	}
+	return fmt.Sprintf("0x%08x", uint32(a.FamilyCode))
+}
+
+// Negotiated returns the USB-PD option the adapter is using, if ioreg lists one
+func (a *AdapterDetails) Negotiated() (UsbHvcOption, bool) {
+	for _, opt := range a.UsbHvcMenu {
+		if opt.Index == a.UsbHvcHvcIndex {
+			return opt, true
+		}
+	}
+	return UsbHvcOption{}, false
+}
+
+// Watts returns the power the option can deliver
+func (o UsbHvcOption) Watts() float64 {
+	return float64(o.MaxVoltage) * float64(o.MaxCurrent) / 1e6
+}
@@ -0,0 +1,50 @@
+package batterylog
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AgentLabel identifies the launchd agent that records samples
+const AgentLabel = "io.github.born1337.macpwr.battery-log"
+
+// AgentPath returns where the launchd agent is installed
+func AgentPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "Library", "LaunchAgents", AgentLabel+".plist")
+}
+
+// AgentPlist returns a launchd agent that runs "macpwr battery log --once"
+// at login and then every interval
+func AgentPlist(executable string, interval time.Duration) string {
+	args := []string{executable, "battery", "log", "--once"}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + AgentLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, arg := range args {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", html.EscapeString(arg))
+	}
+	fmt.Fprintf(&b, `	</array>
+	<key>StartInterval</key>
+	<integer>%d</integer>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, int(interval.Seconds()), html.EscapeString(filepath.Join(filepath.Dir(Path()), "battery-log.err")))
+	return b.String()
+}
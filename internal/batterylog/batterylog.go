@@ -0,0 +1,178 @@
+package batterylog
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/born1337/macpwr/internal/battery"
+	"github.com/born1337/macpwr/internal/jsonl"
+)
+
+// Sample is one reading of the battery, stored as a line of the log
+type Sample struct {
+	Time           time.Time `json:"time"`
+	Charge         int       `json:"charge"`          // in percent
+	Capacity       int       `json:"capacity"`        // current charge, in mAh
+	MaxCapacity    int       `json:"max_capacity"`    // full charge capacity, in mAh
+	DesignCapacity int       `json:"design_capacity"` // in mAh
+	CycleCount     int       `json:"cycle_count"`
+	Temperature    float64   `json:"temperature"`            // in °C
+	Watts          float64   `json:"watts"`                  // into the battery, negative while discharging
+	SystemWatts    float64   `json:"system_watts,omitempty"` // power the Mac was using, if known
+	ExternalPower  bool      `json:"external_power"`
+}
+
+// Health returns the full charge capacity as a percentage of the design
+// capacity, capped at 100%
+func (s Sample) Health() int {
+	if s.DesignCapacity <= 0 {
+		return 0
+	}
+	return min(s.MaxCapacity*100/s.DesignCapacity, 100)
+}
+
+// FromInfo takes a sample from the current battery information
+func FromInfo(info *battery.Info, now time.Time) Sample {
+	d := info.Draw()
+	return Sample{
+		Time:           now,
+		Charge:         info.ChargePercent(),
+		Capacity:       info.ActualCurrentCapacity(),
+		MaxCapacity:    info.ActualMaxCapacity(),
+		DesignCapacity: info.DesignCapacity,
+		CycleCount:     info.CycleCount,
+		Temperature:    float64(info.Temperature) / 100,
+		Watts:          math.Round(d.Battery*100) / 100,
+		SystemWatts:    math.Round(d.System*100) / 100,
+		ExternalPower:  info.ExternalConnected,
+	}
+}
+
+// Path returns the battery log file path
+func Path() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "macpwr", "battery.jsonl")
+}
+
+// Append adds a sample to the end of the log
+func Append(s Sample) error {
+	return jsonl.Append(Path(), s)
+}
+
+// List returns every logged sample, oldest first
+func List() ([]Sample, error) {
+	return jsonl.Read[Sample](Path())
+}
+
+// Between returns the samples taken at or after since and before until.
+// A zero until means no upper bound.
+func Between(samples []Sample, since, until time.Time) []Sample {
+	var out []Sample
+	for _, s := range samples {
+		if s.Time.Before(since) || (!until.IsZero() && !s.Time.Before(until)) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// Resample splits [since, until) into n equal buckets and returns the mean
+// of value over the samples in each. Buckets without samples are NaN.
+func Resample(samples []Sample, since, until time.Time, n int, value func(Sample) float64) []float64 {
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	span := until.Sub(since)
+	for _, s := range samples {
+		if span <= 0 || s.Time.Before(since) || !s.Time.Before(until) {
+			continue
+		}
+		// Scale in floating point: multiplying durations overflows for
+		// ranges of a few years
+		i := int(float64(s.Time.Sub(since)) / float64(span) * float64(n))
+		i = min(max(i, 0), n-1)
+		sums[i] += value(s)
+		counts[i]++
+	}
+
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+		if counts[i] > 0 {
+			out[i] = sums[i] / float64(counts[i])
+		}
+	}
+	return out
+}
+
+// Day summarises the samples from one calendar day
+type Day struct {
+	Date       time.Time
+	Samples    int
+	MinCharge  int
+	MaxCharge  int
+	Health     int // at the last sample of the day
+	CycleCount int // at the last sample of the day
+	MaxTemp    float64
+	AvgWatts   float64 // mean battery power; negative means net discharge
+}
+
+// Days groups samples by local calendar day, oldest first
+func Days(samples []Sample) []Day {
+	var days []Day
+	for _, s := range samples {
+		t := s.Time.Local()
+		date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, Day{Date: date, MinCharge: s.Charge, MaxCharge: s.Charge, MaxTemp: s.Temperature})
+		}
+		d := &days[len(days)-1]
+		d.AvgWatts = (d.AvgWatts*float64(d.Samples) + s.Watts) / float64(d.Samples+1)
+		d.Samples++
+		d.MinCharge = min(d.MinCharge, s.Charge)
+		d.MaxCharge = max(d.MaxCharge, s.Charge)
+		d.MaxTemp = max(d.MaxTemp, s.Temperature)
+		d.Health = s.Health()
+		d.CycleCount = s.CycleCount
+	}
+	return days
+}
+
+// csvHeader names the CSV columns written by WriteCSV
+var csvHeader = []string{
+	"time", "charge", "capacity", "max_capacity", "design_capacity", "health",
+	"cycle_count", "temperature", "watts", "system_watts", "external_power",
+}
+
+// WriteCSV writes samples as CSV with a header row
+func WriteCSV(w io.Writer, samples []Sample) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, s := range samples {
+		record := []string{
+			s.Time.Format(time.RFC3339),
+			strconv.Itoa(s.Charge),
+			strconv.Itoa(s.Capacity),
+			strconv.Itoa(s.MaxCapacity),
+			strconv.Itoa(s.DesignCapacity),
+			strconv.Itoa(s.Health()),
+			strconv.Itoa(s.CycleCount),
+			strconv.FormatFloat(s.Temperature, 'f', -1, 64),
+			strconv.FormatFloat(s.Watts, 'f', -1, 64),
+			strconv.FormatFloat(s.SystemWatts, 'f', -1, 64),
+			strconv.FormatBool(s.ExternalPower),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
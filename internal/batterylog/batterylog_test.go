@@ -0,0 +1,110 @@
+package batterylog
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendAndList(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if samples, err := List(); err != nil || samples != nil {
+		t.Fatalf("List() without a log = %v, %v", samples, err)
+	}
+
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := Append(Sample{Time: now.Add(time.Duration(i) * time.Hour), Charge: 90 - i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	samples, err := List()
+	if err != nil || len(samples) != 3 {
+		t.Fatalf("List() = %v, %v, want 3 samples", samples, err)
+	}
+	if samples[2].Charge != 88 || !samples[2].Time.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("last sample = %+v", samples[2])
+	}
+
+	got := Between(samples, now.Add(time.Hour), now.Add(2*time.Hour))
+	if len(got) != 1 || got[0].Charge != 89 {
+		t.Errorf("Between() = %+v, want the 10:00 sample", got)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	tests := []struct {
+		s    Sample
+		want int
+	}{
+		{Sample{MaxCapacity: 3905, DesignCapacity: 4382}, 89},
+		{Sample{MaxCapacity: 4500, DesignCapacity: 4382}, 100},
+		{Sample{MaxCapacity: 3905}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.s.Health(); got != tt.want {
+			t.Errorf("Health(%d/%d) = %d, want %d", tt.s.MaxCapacity, tt.s.DesignCapacity, got, tt.want)
+		}
+	}
+}
+
+func TestResample(t *testing.T) {
+	since := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	samples := []Sample{
+		{Time: since.Add(10 * time.Minute), Charge: 80},
+		{Time: since.Add(50 * time.Minute), Charge: 60},
+		{Time: since.Add(150 * time.Minute), Charge: 40},
+	}
+
+	got := Resample(samples, since, since.Add(3*time.Hour), 3, func(s Sample) float64 { return float64(s.Charge) })
+	if len(got) != 3 || got[0] != 70 || !math.IsNaN(got[1]) || got[2] != 40 {
+		t.Errorf("Resample() = %v, want [70 NaN 40]", got)
+	}
+
+	// A range of decades must not overflow the bucket arithmetic
+	since = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	samples = []Sample{
+		{Time: since, Charge: 10},
+		{Time: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), Charge: 90},
+	}
+	got = Resample(samples, since, until, 48, func(s Sample) float64 { return float64(s.Charge) })
+	if got[0] != 10 || got[47] != 90 {
+		t.Errorf("Resample() over 26 years = %v, want 10 first and 90 last", got)
+	}
+}
+
+func TestDays(t *testing.T) {
+	day := time.Date(2026, 10, 15, 8, 0, 0, 0, time.Local)
+	samples := []Sample{
+		{Time: day, Charge: 100, Temperature: 29, Watts: -8, CycleCount: 212},
+		{Time: day.Add(6 * time.Hour), Charge: 40, Temperature: 35, Watts: 12, CycleCount: 213},
+		{Time: day.Add(24 * time.Hour), Charge: 70, Temperature: 30, Watts: -4, CycleCount: 213},
+	}
+
+	days := Days(samples)
+	if len(days) != 2 {
+		t.Fatalf("Days() returned %d days, want 2", len(days))
+	}
+	d := days[0]
+	if d.Samples != 2 || d.MinCharge != 40 || d.MaxCharge != 100 || d.MaxTemp != 35 || d.AvgWatts != 2 || d.CycleCount != 213 {
+		t.Errorf("first day = %+v", d)
+	}
+}
+
+func TestAgentPlist(t *testing.T) {
+	p := AgentPlist("/Users/me/bin/mac&pwr", 10*time.Minute)
+	for _, want := range []string{
+		"<string>" + AgentLabel + "</string>",
+		"<string>/Users/me/bin/mac&amp;pwr</string>",
+		"<string>--once</string>",
+		"<integer>600</integer>",
+	} {
+		if !strings.Contains(p, want) {
+			t.Errorf("agent plist is missing %s:\n%s", want, p)
+		}
+	}
+}
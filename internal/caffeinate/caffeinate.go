@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"os/signal"
 	"syscall"
 	"time"
+
+	"github.com/born1337/macpwr/internal/runner"
 )
 
 // Options for caffeinate
@@ -26,11 +27,7 @@ func Run(opts Options) error {
 	if len(opts.Command) > 0 {
 		// Run command while preventing sleep
 		args = append(args, opts.Command...)
-		cmd := exec.Command("caffeinate", args...)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		return cmd.Run()
+		return runner.Default.Run("caffeinate", args...)
 	}
 
 	if opts.Duration > 0 {
@@ -79,8 +76,8 @@ func runWithCountdown(args []string, minutes int) error {
 		cancel()
 	}()
 
-	cmd := exec.CommandContext(ctx, "caffeinate", args...)
-	if err := cmd.Start(); err != nil {
+	proc, err := runner.Default.Start(ctx, "caffeinate", args...)
+	if err != nil {
 		return err
 	}
 
@@ -95,7 +92,6 @@ func runWithCountdown(args []string, minutes int) error {
 		select {
 		case <-ctx.Done():
 			fmt.Println("\n\nCaffeinate cancelled.")
-			cmd.Process.Kill()
 			return nil
 		case <-ticker.C:
 			remaining--
@@ -103,8 +99,8 @@ func runWithCountdown(args []string, minutes int) error {
 		}
 	}
 
-	fmt.Println("\n")
-	return cmd.Wait()
+	fmt.Print("\n\n")
+	return proc.Wait()
 }
 
 func runIndefinitely(args []string) error {
@@ -115,8 +111,8 @@ func runIndefinitely(args []string) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	cmd := exec.CommandContext(ctx, "caffeinate", args...)
-	if err := cmd.Start(); err != nil {
+	proc, err := runner.Default.Start(ctx, "caffeinate", args...)
+	if err != nil {
 		return err
 	}
 
@@ -126,5 +122,5 @@ func runIndefinitely(args []string) error {
 		cancel()
 	}()
 
-	return cmd.Wait()
+	return proc.Wait()
 }
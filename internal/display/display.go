@@ -2,8 +2,11 @@ package display
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"regexp"
 	"strings"
+	"time"
 
 	"golang.org/x/term"
 )
@@ -23,17 +26,29 @@ var (
 func init() {
 	// Disable colors if not a terminal or NO_COLOR is set
 	if !term.IsTerminal(int(os.Stdout.Fd())) || os.Getenv("NO_COLOR") != "" {
-		Red = ""
-		Green = ""
-		Yellow = ""
-		Blue = ""
-		Cyan = ""
-		Bold = ""
-		Dim = ""
-		Reset = ""
+		DisableColor()
 	}
 }
 
+// DisableColor turns off all ANSI color codes
+func DisableColor() {
+	Red = ""
+	Green = ""
+	Yellow = ""
+	Blue = ""
+	Cyan = ""
+	Bold = ""
+	Dim = ""
+	Reset = ""
+}
+
+var ansiCode = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// Plain removes ANSI color codes from s
+func Plain(s string) string {
+	return ansiCode.ReplaceAllString(s, "")
+}
+
 // Header prints a boxed header
 func Header(title string) {
 	width := 58
@@ -56,20 +71,40 @@ func KV(key, value string) {
 	fmt.Printf("  %s%-24s%s %s\n", Dim, key+":", Reset, value)
 }
 
-// TableHeader prints the table header
-func TableHeader() {
-	fmt.Println("┌─────────────────────────┬──────────────┬──────────────┐")
-	fmt.Printf("│ %s%-23s%s │ %s%-12s%s │ %s%-12s%s │\n",
-		Bold, "Setting", Reset,
-		Bold, "Battery", Reset,
-		Bold, "AC Power", Reset)
-	fmt.Println("├─────────────────────────┼──────────────┼──────────────┤")
+// tableCols is the number of value columns in the current table
+var tableCols = 2
+
+// TableHeader prints the table header. Column titles default to Battery and
+// AC Power.
+func TableHeader(columns ...string) {
+	if len(columns) == 0 {
+		columns = []string{"Battery", "AC Power"}
+	}
+	tableCols = len(columns)
+
+	fmt.Println(tableLine("┌", "┬", "┐"))
+	fmt.Printf("│ %s%-23s%s │", Bold, "Setting", Reset)
+	for _, c := range columns {
+		fmt.Printf(" %s%-12s%s │", Bold, c, Reset)
+	}
+	fmt.Println()
+	fmt.Println(tableLine("├", "┼", "┤"))
 }
 
-// TableRow prints a table row
-func TableRow(setting, battery, ac string) {
+// TableRow prints a table row with one value per column
+func TableRow(setting string, values ...string) {
 	// Pad values accounting for invisible ANSI codes
-	fmt.Printf("│ %-23s │ %s │ %s │\n", setting, padWithColor(battery, 12), padWithColor(ac, 12))
+	fmt.Printf("│ %-23s │", setting)
+	for _, v := range values {
+		fmt.Printf(" %s │", padWithColor(v, 12))
+	}
+	fmt.Println()
+}
+
+// tableLine builds a horizontal table border for the current column count
+func tableLine(left, mid, right string) string {
+	return left + strings.Repeat("─", 25) +
+		strings.Repeat(mid+strings.Repeat("─", 14), tableCols) + right
 }
 
 // padWithColor pads a string to width, accounting for ANSI escape codes
@@ -104,12 +139,17 @@ func visibleLen(s string) int {
 
 // TableSep prints a table separator
 func TableSep() {
-	fmt.Println("├─────────────────────────┼──────────────┼──────────────┤")
+	fmt.Println(tableLine("├", "┼", "┤"))
+}
+
+// TableGroup prints a row naming a group of settings
+func TableGroup(title string) {
+	fmt.Printf("│ %s │\n", padWithColor(Bold+title+Reset, 23+15*tableCols))
 }
 
 // TableFooter prints the table footer
 func TableFooter() {
-	fmt.Println("└─────────────────────────┴──────────────┴──────────────┘")
+	fmt.Println(tableLine("└", "┴", "┘"))
 }
 
 // FormatTime formats minutes as a readable time
@@ -123,6 +163,74 @@ func FormatTime(minutes int) string {
 	return fmt.Sprintf("%d min", minutes)
 }
 
+// FormatSeconds formats a duration given in seconds
+func FormatSeconds(seconds int) string {
+	switch {
+	case seconds < 60:
+		return fmt.Sprintf("%d sec", seconds)
+	case seconds%3600 == 0:
+		return fmt.Sprintf("%d h", seconds/3600)
+	default:
+		return FormatTime(seconds / 60)
+	}
+}
+
+// FormatDuration formats a duration as hours and minutes, or seconds when
+// shorter than a minute
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%d sec", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%d min", int(d.Minutes()))
+	}
+	h := int(d.Hours())
+	if m := int(d.Minutes()) % 60; m != 0 {
+		return fmt.Sprintf("%dh %02dm", h, m)
+	}
+	return fmt.Sprintf("%d h", h)
+}
+
+// FormatBytes formats a size in bytes using binary units
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	// Exabytes are as far as an int64 reaches
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// FormatWatts formats a power in watts with one decimal place
+func FormatWatts(w float64) string {
+	return fmt.Sprintf("%.1f W", w)
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a row of block characters scaled between lo and
+// hi. NaN values are drawn as gaps.
+func Sparkline(values []float64, lo, hi float64) string {
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v):
+			b.WriteRune(' ')
+		case hi <= lo:
+			b.WriteRune(sparks[len(sparks)-1])
+		default:
+			i := int((v - lo) / (hi - lo) * float64(len(sparks)-1))
+			b.WriteRune(sparks[min(max(i, 0), len(sparks)-1)])
+		}
+	}
+	return b.String()
+}
+
 // FormatBool formats a boolean as On/Off
 func FormatBool(val bool) string {
 	if val {
@@ -3,9 +3,11 @@ package display
 import (
 	"bytes"
 	"io"
+	"math"
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestVisibleLen(t *testing.T) {
@@ -94,6 +96,107 @@ func TestFormatTime(t *testing.T) {
 	}
 }
 
+func TestFormatSeconds(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    string
+	}{
+		{0, "0 sec"},
+		{45, "45 sec"},
+		{600, "10 min"},
+		{10800, "3 h"},
+		{86400, "24 h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := FormatSeconds(tt.seconds)
+			if got != tt.want {
+				t.Errorf("FormatSeconds(%d) = %q, want %q", tt.seconds, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Second, "45 sec"},
+		{30 * time.Minute, "30 min"},
+		{2 * time.Hour, "2 h"},
+		{2*time.Hour + 32*time.Minute, "2h 32m"},
+		{26*time.Hour + 5*time.Minute, "26h 05m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := FormatDuration(tt.d)
+			if got != tt.want {
+				t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{512, "512 B"},
+		{1536, "1.5 KB"},
+		{2147483648, "2.0 GB"},
+		{1 << 50, "1.0 PB"},
+		{math.MaxInt64, "8.0 EB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := FormatBytes(tt.bytes)
+			if got != tt.want {
+				t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWatts(t *testing.T) {
+	tests := []struct {
+		watts float64
+		want  string
+	}{
+		{0, "0.0 W"},
+		{18.39, "18.4 W"},
+		{-12.05, "-12.1 W"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatWatts(tt.watts); got != tt.want {
+			t.Errorf("FormatWatts(%v) = %q, want %q", tt.watts, got, tt.want)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	got := Sparkline([]float64{0, 50, math.NaN(), 100, 150}, 0, 100)
+	if got != "▁▄ ██" {
+		t.Errorf("Sparkline() = %q, want %q", got, "▁▄ ██")
+	}
+	if got := Sparkline([]float64{89, 89}, 89, 89); got != "██" {
+		t.Errorf("Sparkline(flat) = %q, want %q", got, "██")
+	}
+}
+
+func TestPlain(t *testing.T) {
+	got := Plain("\033[0;32mOn\033[0m, \033[1m5 min\033[0m")
+	if got != "On, 5 min" {
+		t.Errorf("Plain() = %q, want %q", got, "On, 5 min")
+	}
+}
+
 func TestFormatBool(t *testing.T) {
 	// Save original colors and restore after test
 	origGreen := Green
@@ -338,6 +441,27 @@ func TestTableRowAlignment(t *testing.T) {
 	}
 }
 
+func TestTableThreeColumns(t *testing.T) {
+	defer func() { tableCols = 2 }()
+
+	output := captureStdout(func() {
+		TableHeader("Battery", "AC Power", "UPS Power")
+		TableRow("System Sleep", "1 min", "Never", "10 min")
+		TableFooter()
+	})
+
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	width := visibleLen(lines[0])
+	for _, line := range lines {
+		if visibleLen(line) != width {
+			t.Errorf("line %q has width %d, want %d", line, visibleLen(line), width)
+		}
+	}
+	if !strings.Contains(output, "UPS Power") {
+		t.Errorf("TableHeader should contain 'UPS Power', got: %s", output)
+	}
+}
+
 func TestTableSep(t *testing.T) {
 	output := captureStdout(func() {
 		TableSep()
@@ -351,6 +475,19 @@ func TestTableSep(t *testing.T) {
 	}
 }
 
+func TestTableGroup(t *testing.T) {
+	output := captureStdout(func() {
+		TableGroup("Wake")
+	})
+
+	if !strings.Contains(output, "Wake") {
+		t.Errorf("TableGroup should contain title, got: %s", output)
+	}
+	if strings.Count(output, "│") != 2 {
+		t.Errorf("TableGroup should span the table with 2 pipe chars, got: %s", output)
+	}
+}
+
 func TestTableFooter(t *testing.T) {
 	output := captureStdout(func() {
 		TableFooter()
@@ -0,0 +1,65 @@
+package enforce
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/born1337/macpwr/internal/plan"
+)
+
+// Drift returns the settings in a plan whose current value differs from
+// the pinned value. Settings this Mac doesn't report can't be compared and
+// are left out; see Unreported.
+func Drift(p *plan.Plan) []plan.Change {
+	var drift []plan.Change
+	for _, c := range p.Changes() {
+		if c.Known && c.Changed() {
+			drift = append(drift, c)
+		}
+	}
+	return drift
+}
+
+// Unreported returns the pinned settings this Mac doesn't report for their
+// power source
+func Unreported(p *plan.Plan) []plan.Change {
+	var unknown []plan.Change
+	for _, c := range p.Changes() {
+		if !c.Known {
+			unknown = append(unknown, c)
+		}
+	}
+	return unknown
+}
+
+// LogPath returns the drift log file path
+func LogPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "macpwr", "drift.log")
+}
+
+// Log appends one line per drifted setting to the drift log
+func Log(pinned string, drift []plan.Change, fixed bool) error {
+	if err := os.MkdirAll(filepath.Dir(LogPath()), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(LogPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	action := "detected"
+	if fixed {
+		action = "fixed"
+	}
+	now := time.Now().Format(time.RFC3339)
+	for _, c := range drift {
+		fmt.Fprintf(f, "%s %s %s %s=%d pinned=%d %s\n",
+			now, pinned, c.Source, c.Key, c.Before, c.After, action)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/settings"
+)
+
+// Formats lists the supported export formats
+var Formats = []string{"sh"}
+
+// Targets returns every supported setting pmset reports, per power source,
+// in the form a plan would hold them
+func Targets(s *settings.AllSettings) []plan.Target {
+	var targets []plan.Target
+	for _, src := range s.Available() {
+		cur := s.For(src)
+		t := plan.Target{Source: src}
+		for _, k := range settings.Keys {
+			if !cur.Has(k.Name) || !s.Caps.Supports(src, k.Name) {
+				continue
+			}
+			val, _ := cur.Value(k.Name)
+			t.Values = append(t.Values, plan.Value{Key: k.Name, Value: val})
+		}
+		if len(t.Values) > 0 {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// header is the start of every shell script. apply reads the current value
+// from pmset -g custom so that running the script again changes nothing, and
+// skips keys the Mac does not report instead of failing on them.
+const header = `set -eu
+
+# apply FLAG SOURCE KEY VALUE sets KEY for SOURCE unless it already has VALUE
+apply() {
+	current=$(pmset -g custom | awk -v section="$2:" -v key="$3" '
+		/^[^ ]/ { in_section = ($0 == section) }
+		in_section && $1 == key { print $2; exit }')
+	if [ -z "$current" ]; then
+		echo "skipping $3: not supported on $2" >&2
+	elif [ "$current" != "$4" ]; then
+		echo "$2: $3 $current -> $4"
+		sudo pmset "$1" "$3" "$4"
+	fi
+}
+`
+
+// Shell writes a POSIX shell script that applies the targets with pmset.
+// title names what was exported and describe returns the comment for each
+// value, such as "Display Sleep: 5 min".
+func Shell(w io.Writer, title string, targets []plan.Target, describe func(key string, val int) string) error {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Power settings exported by macpwr from %s.\n", title)
+	b.WriteString("# Replays with pmset alone; settings already in place are left alone,\n")
+	b.WriteString("# so the script can be run any number of times.\n\n")
+	b.WriteString(header)
+
+	for _, t := range targets {
+		section := string(t.Source) + " Power"
+		lines := make([]string, len(t.Values))
+		width := 0
+		for i, v := range t.Values {
+			lines[i] = strings.Join([]string{"apply", t.Source.Flag(), strconv.Quote(section), v.Key, strconv.Itoa(v.Value)}, " ")
+			width = max(width, len(lines[i]))
+		}
+
+		fmt.Fprintf(&b, "\n# %s\n", t.Source.Label())
+		for i, v := range t.Values {
+			if comment := describe(v.Key, v.Value); comment != "" {
+				fmt.Fprintf(&b, "%-*s  # %s\n", width, lines[i], comment)
+			} else {
+				b.WriteString(lines[i] + "\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
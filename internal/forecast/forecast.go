@@ -0,0 +1,239 @@
+package forecast
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/born1337/macpwr/internal/battery"
+	"github.com/born1337/macpwr/internal/jsonl"
+)
+
+// Threshold is the health, in percent of design capacity, below which Apple
+// recommends servicing the battery
+const Threshold = 80.0
+
+const (
+	// minSpan is how long readings must cover before their trend is trusted
+	minSpan = 30 * 24 * time.Hour
+	// minCycles is how many cycles readings must cover for the same
+	minCycles = 10
+	// maxDays and maxCycles bound projections of a nearly flat trend
+	maxDays   = 50 * 365
+	maxCycles = 100000
+)
+
+// Reasons a declining trend gives no Date or Cycles
+const (
+	// Noisy means the fitted line crosses Threshold before the latest
+	// reading, although that reading is above it
+	Noisy = "noisy"
+	// Slow means health declines too slowly to reach Threshold within
+	// maxDays or maxCycles
+	Slow = "slow"
+)
+
+// Reading is one health measurement, stored as a line of the record
+type Reading struct {
+	Time           time.Time `json:"time"`
+	MaxCapacity    int       `json:"max_capacity"`    // full charge capacity, in mAh
+	DesignCapacity int       `json:"design_capacity"` // in mAh
+	CycleCount     int       `json:"cycle_count"`
+	Serial         string    `json:"serial,omitempty"`
+}
+
+// Health returns the full charge capacity as a percentage of the design
+// capacity. Unlike battery.Info.HealthPercent it is not capped, since a new
+// battery often starts above 100%.
+func (r Reading) Health() float64 {
+	if r.DesignCapacity <= 0 {
+		return 0
+	}
+	return float64(r.MaxCapacity) * 100 / float64(r.DesignCapacity)
+}
+
+// FromInfo takes a reading from the current battery information
+func FromInfo(info *battery.Info, now time.Time) Reading {
+	return Reading{
+		Time:           now,
+		MaxCapacity:    info.ActualMaxCapacity(),
+		DesignCapacity: info.DesignCapacity,
+		CycleCount:     info.CycleCount,
+		Serial:         info.Serial,
+	}
+}
+
+// Path returns the health record file path
+func Path() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "macpwr", "battery-health.jsonl")
+}
+
+// List returns every recorded reading, oldest first
+func List() ([]Reading, error) {
+	return jsonl.Read[Reading](Path())
+}
+
+// Record appends r to the record, reporting false if it was skipped. To
+// keep the record small, a reading that matches the last one is only stored
+// once a day has passed.
+func Record(r Reading) (bool, error) {
+	readings, err := List()
+	if err != nil {
+		return false, err
+	}
+	if n := len(readings); n > 0 {
+		last := readings[n-1]
+		if last.MaxCapacity == r.MaxCapacity && last.DesignCapacity == r.DesignCapacity &&
+			last.CycleCount == r.CycleCount && last.Serial == r.Serial && r.Time.Sub(last.Time) < 24*time.Hour {
+			return false, nil
+		}
+	}
+	if err := jsonl.Append(Path(), r); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Current returns the readings taken from the same battery as the last
+// one. A new serial number, design capacity or a cycle count that goes
+// backwards means the battery was replaced.
+func Current(readings []Reading) []Reading {
+	if len(readings) == 0 {
+		return nil
+	}
+	i := len(readings) - 1
+	for ; i > 0; i-- {
+		prev, r := readings[i-1], readings[i]
+		if prev.Serial != r.Serial || prev.DesignCapacity != r.DesignCapacity || prev.CycleCount > r.CycleCount {
+			break
+		}
+	}
+	return readings[i:]
+}
+
+// Estimate projects when a battery's health reaches Threshold
+type Estimate struct {
+	Health     float64   `json:"health"` // at the latest reading
+	CycleCount int       `json:"cycle_count"`
+	Readings   int       `json:"readings"`
+	First      time.Time `json:"first_reading"`
+
+	// Health change per year and per 100 cycles; negative while degrading.
+	// Nil when the readings don't show a trend.
+	PerYear      *float64 `json:"health_per_year"`
+	Per100Cycles *float64 `json:"health_per_100_cycles"`
+
+	// When and at what cycle count health reaches Threshold. Nil when it is
+	// not declining, already below Threshold, or for the reason given.
+	Date         *time.Time `json:"date"`
+	Cycles       *int       `json:"cycles"`
+	DateReason   string     `json:"date_reason,omitempty"`   // Noisy or Slow
+	CyclesReason string     `json:"cycles_reason,omitempty"` // Noisy or Slow
+
+	Reached    bool `json:"reached"`     // health is already below Threshold
+	AssumesNew bool `json:"assumes_new"` // too few readings, so 100% health when new was assumed
+}
+
+// Project fits a straight line to health over time and over cycles for the
+// current battery's readings. Until the readings span minSpan and
+// minCycles, the fit also assumes the battery had 100% health when it was
+// made and at 0 cycles; made is zero if the manufacture date is unknown.
+func Project(readings []Reading, made time.Time) Estimate {
+	readings = Current(readings)
+	if len(readings) == 0 {
+		return Estimate{}
+	}
+	first, last := readings[0], readings[len(readings)-1]
+	e := Estimate{
+		Health:     last.Health(),
+		CycleCount: last.CycleCount,
+		Readings:   len(readings),
+		First:      first.Time,
+		Reached:    last.Health() < Threshold,
+	}
+
+	// Health over time, with x in days since origin
+	origin := first.Time
+	var xs, ys []float64
+	if last.Time.Sub(first.Time) < minSpan && !made.IsZero() && made.Before(first.Time) {
+		origin = made
+		xs, ys = append(xs, 0), append(ys, 100)
+		e.AssumesNew = true
+	}
+	for _, r := range readings {
+		xs = append(xs, r.Time.Sub(origin).Hours()/24)
+		ys = append(ys, r.Health())
+	}
+	if slope, intercept, ok := fitLine(xs, ys); ok {
+		perYear := slope * 365
+		e.PerYear = &perYear
+		if slope < 0 && !e.Reached {
+			now := last.Time.Sub(origin).Hours() / 24
+			switch days := (Threshold - intercept) / slope; {
+			case days <= now:
+				e.DateReason = Noisy
+			case days >= maxDays:
+				e.DateReason = Slow
+			default:
+				date := origin.Add(time.Duration(days * 24 * float64(time.Hour))).Truncate(24 * time.Hour)
+				e.Date = &date
+			}
+		}
+	}
+
+	// Health over cycles
+	xs, ys = nil, nil
+	if last.CycleCount-first.CycleCount < minCycles {
+		xs, ys = append(xs, 0), append(ys, 100)
+		e.AssumesNew = true
+	}
+	for _, r := range readings {
+		xs = append(xs, float64(r.CycleCount))
+		ys = append(ys, r.Health())
+	}
+	if slope, intercept, ok := fitLine(xs, ys); ok {
+		per100 := slope * 100
+		e.Per100Cycles = &per100
+		if slope < 0 && !e.Reached {
+			switch cycles := (Threshold - intercept) / slope; {
+			case cycles <= float64(last.CycleCount):
+				e.CyclesReason = Noisy
+			case cycles >= maxCycles:
+				e.CyclesReason = Slow
+			default:
+				n := int(math.Ceil(cycles))
+				e.Cycles = &n
+			}
+		}
+	}
+
+	return e
+}
+
+// fitLine returns the least squares line y = slope*x + intercept, reporting
+// false if the xs don't vary
+func fitLine(xs, ys []float64) (slope, intercept float64, ok bool) {
+	n := float64(len(xs))
+	if len(xs) < 2 {
+		return 0, 0, false
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx, my = mx/n, my/n
+
+	var sxx, sxy float64
+	for i := range xs {
+		sxx += (xs[i] - mx) * (xs[i] - mx)
+		sxy += (xs[i] - mx) * (ys[i] - my)
+	}
+	if sxx == 0 {
+		return 0, 0, false
+	}
+	slope = sxy / sxx
+	return slope, my - slope*mx, true
+}
@@ -0,0 +1,173 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+)
+
+// decline returns monthly readings losing 0.5% of a 5000 mAh design
+// capacity and gaining 15 cycles each month
+func decline(start time.Time, months int) []Reading {
+	var readings []Reading
+	for i := 0; i < months; i++ {
+		readings = append(readings, Reading{
+			Time:           start.AddDate(0, i, 0),
+			MaxCapacity:    4750 - i*25,
+			DesignCapacity: 5000,
+			CycleCount:     100 + i*15,
+			Serial:         "F8Y2345",
+		})
+	}
+	return readings
+}
+
+func TestProject(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := Project(decline(start, 10), time.Time{})
+
+	if e.Readings != 10 || e.CycleCount != 235 || e.Health != 90.5 || e.AssumesNew || e.Reached {
+		t.Errorf("Project() = %+v", e)
+	}
+	// 95% at 100 cycles, -0.5% every 15 cycles: 80% at 550 cycles
+	if e.Cycles == nil || *e.Cycles != 550 {
+		t.Errorf("Cycles = %v, want 550", e.Cycles)
+	}
+	if e.Per100Cycles == nil || *e.Per100Cycles > -3.3 || *e.Per100Cycles < -3.4 {
+		t.Errorf("Per100Cycles = %v, want about -3.33", e.Per100Cycles)
+	}
+	// 95% at the start, -6% a year: 80% about 2.5 years later
+	if e.Date == nil || e.Date.Year() != 2028 || e.Date.Month() != time.June {
+		t.Errorf("Date = %v, want June 2028", e.Date)
+	}
+}
+
+func TestProjectAssumesNew(t *testing.T) {
+	made := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := Reading{Time: made.AddDate(1, 0, 0), MaxCapacity: 4500, DesignCapacity: 5000, CycleCount: 200}
+
+	e := Project([]Reading{r}, made)
+	if !e.AssumesNew {
+		t.Error("AssumesNew = false for a single reading")
+	}
+	// 100% new, 90% after 200 cycles and a year: 80% at 400 cycles, a year on
+	if e.Cycles == nil || *e.Cycles != 400 {
+		t.Errorf("Cycles = %v, want 400", e.Cycles)
+	}
+	if e.Date == nil || e.Date.Year() != 2027 {
+		t.Errorf("Date = %v, want early 2027", e.Date)
+	}
+
+	// Without a manufacture date there is no time trend
+	e = Project([]Reading{r}, time.Time{})
+	if e.Date != nil || e.PerYear != nil {
+		t.Errorf("Project() without a manufacture date = %+v", e)
+	}
+}
+
+func TestProjectNotDeclining(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	readings := decline(start, 6)
+	for i := range readings {
+		readings[i].MaxCapacity = 4750
+	}
+
+	e := Project(readings, time.Time{})
+	if e.Date != nil || e.Cycles != nil {
+		t.Errorf("Project() of a flat trend = %+v, want no estimate", e)
+	}
+
+	readings[len(readings)-1].MaxCapacity = 3900
+	if e := Project(readings, time.Time{}); !e.Reached || e.Date != nil {
+		t.Errorf("Project() below the threshold = %+v, want Reached", e)
+	}
+}
+
+func TestProjectNoisy(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Health per reading, ten days and ten cycles apart; the last is above
+	// the threshold but the fitted line is already below it
+	tests := []struct {
+		name   string
+		health []int
+	}{
+		// The fit crosses 80% before the first reading
+		{"before first", []int{79, 79, 79, 50, 81}},
+		// The fit crosses 80% between the first and latest readings
+		{"before latest", []int{95, 90, 60, 55, 81}},
+	}
+	for _, tt := range tests {
+		var readings []Reading
+		for i, h := range tt.health {
+			readings = append(readings, Reading{
+				Time:           start.AddDate(0, 0, i*10),
+				MaxCapacity:    h * 10,
+				DesignCapacity: 1000,
+				CycleCount:     i * 10,
+			})
+		}
+		e := Project(readings, time.Time{})
+		if e.Reached || e.Date != nil || e.Cycles != nil || e.DateReason != Noisy || e.CyclesReason != Noisy {
+			t.Errorf("%s: Project() = %+v, want no estimate as the readings are noisy", tt.name, e)
+		}
+	}
+}
+
+func TestProjectSlow(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 1 mAh of a 50000 mAh design lost per year and per 100 cycles
+	var readings []Reading
+	for i := 0; i < 4; i++ {
+		readings = append(readings, Reading{
+			Time:           start.AddDate(i, 0, 0),
+			MaxCapacity:    49000 - i,
+			DesignCapacity: 50000,
+			CycleCount:     i * 100,
+		})
+	}
+
+	e := Project(readings, time.Time{})
+	if e.Date != nil || e.Cycles != nil || e.DateReason != Slow || e.CyclesReason != Slow {
+		t.Errorf("Project() = %+v, want no estimate as the decline is too slow", e)
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	readings := decline(start, 4)
+	replaced := Reading{Time: start.AddDate(0, 5, 0), MaxCapacity: 5100, DesignCapacity: 5000, CycleCount: 2, Serial: "G7K0001"}
+	readings = append(readings, replaced)
+
+	if got := Current(readings); len(got) != 1 || got[0] != replaced {
+		t.Errorf("Current() = %+v, want only the replacement battery", got)
+	}
+	if got := Current(readings[:4]); len(got) != 4 {
+		t.Errorf("Current() kept %d readings, want 4", len(got))
+	}
+}
+
+func TestRecord(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	r := Reading{Time: now, MaxCapacity: 3905, DesignCapacity: 4382, CycleCount: 213}
+	steps := []struct {
+		r    Reading
+		want bool
+	}{
+		{r, true},
+		{Reading{Time: now.Add(time.Hour), MaxCapacity: 3905, DesignCapacity: 4382, CycleCount: 213}, false},
+		{Reading{Time: now.Add(2 * time.Hour), MaxCapacity: 3901, DesignCapacity: 4382, CycleCount: 213}, true},
+		{Reading{Time: now.Add(30 * time.Hour), MaxCapacity: 3901, DesignCapacity: 4382, CycleCount: 213}, true},
+	}
+	for i, s := range steps {
+		got, err := Record(s.r)
+		if err != nil || got != s.want {
+			t.Errorf("step %d: Record() = %v, %v, want %v", i, got, err, s.want)
+		}
+	}
+
+	readings, err := List()
+	if err != nil || len(readings) != 3 {
+		t.Errorf("List() = %d readings, %v, want 3", len(readings), err)
+	}
+}
@@ -0,0 +1,88 @@
+package hibernate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/runner"
+)
+
+// DefaultSleepImage is where macOS keeps the hibernation image
+const DefaultSleepImage = "/var/vm/sleepimage"
+
+// Mode describes a hibernatemode value
+type Mode struct {
+	Value       int
+	Name        string
+	Description string
+}
+
+// Modes lists the supported hibernatemode values
+var Modes = []Mode{
+	{0, "RAM only", "Memory stays powered during sleep and is not written to disk. Fastest sleep and wake, but unsaved work is lost if power runs out. Default on desktops."},
+	{3, "Safe sleep", "Memory stays powered and is also copied to the sleep image, so the session survives a drained battery. Default on laptops."},
+	{25, "Hibernate", "Memory is written to the sleep image and then powered off. Lowest drain while asleep, slowest wake."},
+}
+
+// Explanations describes what each hibernation and standby key controls
+var Explanations = map[string]string{
+	"standby":              "After sleeping for the standby delay, write memory to the sleep image and power it off.",
+	"standbydelaylow":      "Standby delay used while the battery is below the high standby threshold.",
+	"standbydelayhigh":     "Standby delay used while the battery is above the high standby threshold.",
+	"highstandbythreshold": "Battery level that selects between the low and high standby delay.",
+	"autopoweroff":         "After the auto power off delay, enter a deeper hibernation state (mostly Intel Macs).",
+	"autopoweroffdelay":    "How long to sleep before auto power off.",
+}
+
+// LookupMode returns the description of a hibernatemode value
+func LookupMode(value int) (Mode, bool) {
+	for _, m := range Modes {
+		if m.Value == value {
+			return m, true
+		}
+	}
+	return Mode{}, false
+}
+
+// Risks returns a warning for every value that can lose data. laptop is
+// true when the machine runs on battery power.
+func Risks(values map[string]int, laptop bool) []string {
+	var risks []string
+	if mode, ok := values["hibernatemode"]; ok && mode == 0 && laptop {
+		risks = append(risks, "hibernatemode 0 keeps no sleep image: if the battery runs out while asleep, all unsaved work is lost")
+	}
+	return risks
+}
+
+// SleepImage describes the hibernation image on disk
+type SleepImage struct {
+	Path   string
+	Size   int64
+	Exists bool
+}
+
+// GetSleepImage reports the size of the sleep image at path
+func GetSleepImage(path string) SleepImage {
+	if path == "" {
+		path = DefaultSleepImage
+	}
+	img := SleepImage{Path: path}
+
+	out, err := runner.Default.Output("stat", "-f", "%z", path)
+	if err != nil {
+		return img
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return img
+	}
+	img.Size = size
+	img.Exists = true
+	return img
+}
+
+// String describes a hibernatemode value, such as "3 (Safe sleep)"
+func (m Mode) String() string {
+	return fmt.Sprintf("%d (%s)", m.Value, m.Name)
+}
@@ -0,0 +1,74 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/born1337/macpwr/internal/jsonl"
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/settings"
+)
+
+// Change records one setting changed by a command
+type Change struct {
+	Source settings.Source `json:"source"`
+	Key    string          `json:"key"`
+	Before *int            `json:"before"` // nil if the old value was unknown
+	After  int             `json:"after"`
+}
+
+// Entry is one line of the journal
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Changes []Change  `json:"changes"`
+}
+
+// Path returns the journal file path
+func Path() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "macpwr", "journal.jsonl")
+}
+
+// Append adds an entry to the end of the journal
+func Append(e Entry) error {
+	return jsonl.Append(Path(), e)
+}
+
+// List returns all journal entries, oldest first
+func List() ([]Entry, error) {
+	return jsonl.Read[Entry](Path())
+}
+
+// FromPlan builds a journal entry for the settings a plan changed
+func FromPlan(command string, p *plan.Plan) Entry {
+	e := Entry{Time: time.Now(), Command: command}
+	for _, c := range p.Changes() {
+		if !c.Changed() {
+			continue
+		}
+		jc := Change{Source: c.Source, Key: c.Key, After: c.After}
+		if c.Known {
+			before := c.Before
+			jc.Before = &before
+		}
+		e.Changes = append(e.Changes, jc)
+	}
+	return e
+}
+
+// UndoPlan returns a plan restoring the state before entries[n], undoing
+// that entry and every later one. Keys whose old value is unknown are left
+// untouched.
+func UndoPlan(entries []Entry, n int, current *settings.AllSettings) *plan.Plan {
+	p := plan.New(current)
+	for i := len(entries) - 1; i >= n; i-- {
+		for _, c := range entries[i].Changes {
+			if c.Before != nil {
+				p.Set(c.Source, c.Key, *c.Before)
+			}
+		}
+	}
+	return p
+}
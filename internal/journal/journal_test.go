@@ -0,0 +1,58 @@
+package journal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/born1337/macpwr/internal/settings"
+)
+
+func intp(v int) *int { return &v }
+
+func TestAppendList(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, cmd := range []string{"set --ac --sleep=10", "preset movie"} {
+		if err := Append(Entry{Time: time.Now(), Command: cmd}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Command != "preset movie" {
+		t.Errorf("List() = %+v", entries)
+	}
+}
+
+func TestUndoPlan(t *testing.T) {
+	entries := []Entry{
+		{Command: "set --ac --sleep=10", Changes: []Change{
+			{Source: settings.AC, Key: "sleep", Before: intp(0), After: 10},
+		}},
+		{Command: "set --ac --sleep=20 --display=5", Changes: []Change{
+			{Source: settings.AC, Key: "sleep", Before: intp(10), After: 20},
+			{Source: settings.AC, Key: "displaysleep", Before: intp(10), After: 5},
+			{Source: settings.Battery, Key: "disksleep", Before: nil, After: 5},
+		}},
+	}
+
+	p := UndoPlan(entries, 0, nil)
+	invs := p.Invocations()
+	if len(invs) != 1 {
+		t.Fatalf("Invocations() = %+v, want a single AC call", invs)
+	}
+
+	want := map[string]int{"sleep": 0, "displaysleep": 10}
+	for _, v := range invs[0].Values {
+		if want[v.Key] != v.Value {
+			t.Errorf("%s restored to %d, want %d", v.Key, v.Value, want[v.Key])
+		}
+		delete(want, v.Key)
+	}
+	if len(want) != 0 {
+		t.Errorf("keys not restored: %v", want)
+	}
+}
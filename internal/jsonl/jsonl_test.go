@@ -0,0 +1,36 @@
+package jsonl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type entry struct {
+	N int `json:"n"`
+}
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "log.jsonl")
+
+	if items, err := Read[entry](path); err != nil || items != nil {
+		t.Fatalf("Read() of a missing file = %v, %v", items, err)
+	}
+
+	for n := 1; n <= 2; n++ {
+		if err := Append(path, entry{n}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("{corrupt\n")
+	f.Close()
+	Append(path, entry{3})
+
+	items, err := Read[entry](path)
+	want := []entry{{1}, {2}, {3}}
+	if err != nil || !reflect.DeepEqual(items, want) {
+		t.Errorf("Read() = %v, %v, want %v", items, err, want)
+	}
+}
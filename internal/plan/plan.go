@@ -0,0 +1,433 @@
+package plan
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/born1337/macpwr/internal/runner"
+	"github.com/born1337/macpwr/internal/settings"
+)
+
+// Value is a single pmset key and its desired value
+type Value struct {
+	Key   string
+	Value int
+}
+
+// Target holds the desired values for one power source
+type Target struct {
+	Source settings.Source
+	Values []Value
+}
+
+// Invocation is a single pmset command, targeting either one power source
+// or every power source at once (pmset -a)
+type Invocation struct {
+	All    bool
+	Source settings.Source // when !All
+	Values []Value
+}
+
+// Flag returns the pmset power source flag for the invocation
+func (inv Invocation) Flag() string {
+	if inv.All {
+		return "-a"
+	}
+	return inv.Source.Flag()
+}
+
+// Label returns a human-readable description of what the invocation targets
+func (inv Invocation) Label() string {
+	if inv.All {
+		return "all power sources"
+	}
+	return inv.Source.Label()
+}
+
+// Args returns the pmset arguments for the invocation
+func (inv Invocation) Args() []string {
+	args := []string{inv.Flag()}
+	for _, v := range inv.Values {
+		args = append(args, v.Key, strconv.Itoa(v.Value))
+	}
+	return args
+}
+
+// Change describes how a setting will change when a plan is executed
+type Change struct {
+	Source settings.Source
+	Key    string
+	Before int
+	After  int
+	Known  bool // false if the current value could not be read
+}
+
+// Changed reports whether the setting's value will actually change
+func (c Change) Changed() bool {
+	return !c.Known || c.Before != c.After
+}
+
+// Plan describes a desired state across power sources and how to reach it
+// with as few pmset invocations as possible
+type Plan struct {
+	Targets []Target
+	Before  *settings.AllSettings
+}
+
+// New returns an empty plan. before holds the current settings and is used
+// to skip values that are already in place, merge identical values into a
+// single pmset -a call and describe changes; it may be nil.
+func New(before *settings.AllSettings) *Plan {
+	return &Plan{Before: before}
+}
+
+// Set records the desired value of a key for a power source, replacing any
+// earlier value
+func (p *Plan) Set(src settings.Source, key string, val int) {
+	t := p.target(src)
+	for i := range t.Values {
+		if t.Values[i].Key == key {
+			t.Values[i].Value = val
+			return
+		}
+	}
+	t.Values = append(t.Values, Value{Key: key, Value: val})
+}
+
+// SetSupported records the desired values of several keys for a power
+// source in a stable order, skipping keys this Mac does not support so one
+// preset or profile works on every model
+func (p *Plan) SetSupported(src settings.Source, values map[string]int) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if p.Before != nil && !p.Before.Caps.Supports(src, key) {
+			continue
+		}
+		p.Set(src, key, values[key])
+	}
+}
+
+func (p *Plan) target(src settings.Source) *Target {
+	for i := range p.Targets {
+		if p.Targets[i].Source == src {
+			return &p.Targets[i]
+		}
+	}
+	p.Targets = append(p.Targets, Target{Source: src})
+	return &p.Targets[len(p.Targets)-1]
+}
+
+// desired returns the desired value of a key for a power source
+func (p *Plan) desired(src settings.Source, key string) (int, bool) {
+	for _, t := range p.Targets {
+		if t.Source != src {
+			continue
+		}
+		for _, v := range t.Values {
+			if v.Key == key {
+				return v.Value, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// current returns the value of a key before the plan runs
+func (p *Plan) current(src settings.Source, key string) (int, bool) {
+	if p.Before == nil {
+		return 0, false
+	}
+	cur := p.Before.For(src)
+	if cur == nil || !cur.Has(key) {
+		return 0, false
+	}
+	return cur.Value(key)
+}
+
+// Validate checks every desired value against its allowed range and the
+// cross-key rules for the settings each power source will end up with
+func (p *Plan) Validate() error {
+	var problems []string
+	for _, t := range p.Targets {
+		result := make(map[string]int)
+		if p.Before != nil {
+			if cur := p.Before.For(t.Source); cur != nil {
+				for _, k := range settings.Keys {
+					if cur.Has(k.Name) {
+						result[k.Name], _ = cur.Value(k.Name)
+					}
+				}
+			}
+		}
+
+		changed := make(map[string]bool)
+		for _, v := range t.Values {
+			if err := settings.ValidateValue(v.Key, v.Value); err != nil {
+				problems = append(problems, t.Source.Label()+": "+err.Error())
+				continue
+			}
+			if err := settings.ValidateSource(v.Key, t.Source); err != nil {
+				problems = append(problems, t.Source.Label()+": "+err.Error())
+				continue
+			}
+			if p.Before != nil {
+				if err := p.Before.Caps.Check(t.Source, v.Key); err != nil {
+					problems = append(problems, t.Source.Label()+": "+err.Error())
+					continue
+				}
+			}
+			result[v.Key] = v.Value
+			changed[v.Key] = true
+		}
+
+		for _, problem := range settings.ValidateCombination(result, changed) {
+			problems = append(problems, t.Source.Label()+": "+problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &settings.ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Empty reports whether the plan has nothing to do
+func (p *Plan) Empty() bool {
+	return len(p.Invocations()) == 0
+}
+
+// Changes returns the before/after value of every key the plan touches
+func (p *Plan) Changes() []Change {
+	var changes []Change
+	for _, t := range p.Targets {
+		for _, v := range t.Values {
+			c := Change{Source: t.Source, Key: v.Key, After: v.Value}
+			c.Before, c.Known = p.current(t.Source, v.Key)
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// Invocations returns the minimal set of pmset invocations that reach the
+// desired state. Values already in place are skipped, and keys with the same
+// desired value on every power source are merged into one pmset -a call when
+// that saves an invocation.
+func (p *Plan) Invocations() []Invocation {
+	// Keys that still need changing, per source
+	var pending []Invocation
+	for _, t := range p.Targets {
+		inv := Invocation{Source: t.Source}
+		for _, v := range t.Values {
+			if cur, ok := p.current(t.Source, v.Key); ok && cur == v.Value {
+				continue
+			}
+			inv.Values = append(inv.Values, v)
+		}
+		if len(inv.Values) > 0 {
+			pending = append(pending, inv)
+		}
+	}
+
+	if p.Before == nil {
+		return pending
+	}
+	available := p.Before.Available()
+	if len(available) < 2 {
+		return pending
+	}
+
+	// Split pending values into those that can go in a single -a call
+	all := Invocation{All: true}
+	var rest []Invocation
+	for _, inv := range pending {
+		remaining := Invocation{Source: inv.Source}
+		for _, v := range inv.Values {
+			if p.sameEverywhere(available, v) {
+				if !hasKey(all.Values, v.Key) {
+					all.Values = append(all.Values, v)
+				}
+				continue
+			}
+			remaining.Values = append(remaining.Values, v)
+		}
+		if len(remaining.Values) > 0 {
+			rest = append(rest, remaining)
+		}
+	}
+
+	if len(all.Values) == 0 || 1+len(rest) >= len(pending) {
+		return pending
+	}
+	return append([]Invocation{all}, rest...)
+}
+
+// sameEverywhere reports whether v is the desired value on every source
+func (p *Plan) sameEverywhere(sources []settings.Source, v Value) bool {
+	for _, src := range sources {
+		if want, ok := p.desired(src, v.Key); !ok || want != v.Value {
+			return false
+		}
+	}
+	return true
+}
+
+func hasKey(values []Value, key string) bool {
+	for _, v := range values {
+		if v.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Commands returns the exact command lines Execute runs. When more than one
+// pmset call is needed, sudo is authorised once up front and the pmset calls
+// run non-interactively, so the user sees at most one password prompt.
+func (p *Plan) Commands() [][]string {
+	return commands(p.Invocations(), false)
+}
+
+func commands(invs []Invocation, authorised bool) [][]string {
+	if len(invs) == 0 {
+		return nil
+	}
+
+	var cmds [][]string
+	sudo := []string{"sudo"}
+	if authorised || len(invs) > 1 {
+		if !authorised {
+			cmds = append(cmds, []string{"sudo", "-v"})
+		}
+		sudo = []string{"sudo", "-n"}
+	}
+	for _, inv := range invs {
+		cmd := append(append([]string{}, sudo...), "pmset")
+		cmds = append(cmds, append(cmd, inv.Args()...))
+	}
+	return cmds
+}
+
+// Execute applies the plan as a transaction. It snapshots the current
+// settings first; if any invocation fails, every key touched so far is
+// restored from the snapshot and a *RollbackError describing the restore is
+// returned.
+func (p *Plan) Execute() error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	invs := p.Invocations()
+	if len(invs) == 0 {
+		return nil
+	}
+
+	snapshot, err := settings.Get()
+	if err != nil {
+		return fmt.Errorf("failed to snapshot current settings: %w", err)
+	}
+
+	authorised := len(invs) > 1
+	if authorised {
+		if err := runner.Default.Run("sudo", "-v"); err != nil {
+			return fmt.Errorf("sudo authorisation failed: %w", err)
+		}
+	}
+
+	for i, cmd := range commands(invs, authorised) {
+		if err := runner.Default.Run(cmd[0], cmd[1:]...); err != nil {
+			return rollback(snapshot, invs[:i+1], &StepError{Invocation: invs[i], Err: err})
+		}
+	}
+	return nil
+}
+
+// rollback restores the keys touched by invs that no longer hold their
+// snapshot values. If the settings cannot be re-read, every touched key is
+// restored.
+func rollback(snapshot *settings.AllSettings, invs []Invocation, cause error) error {
+	after, _ := settings.Get()
+	restore := New(nil)
+	rbErr := &RollbackError{Err: cause}
+
+	for _, inv := range invs {
+		sources := []settings.Source{inv.Source}
+		if inv.All {
+			sources = snapshot.Available()
+		}
+
+		for _, src := range sources {
+			cur := snapshot.For(src)
+			if cur == nil {
+				continue
+			}
+			for _, v := range inv.Values {
+				if !cur.Has(v.Key) {
+					continue
+				}
+				old, _ := cur.Value(v.Key)
+				now := v.Value
+				if after != nil && after.For(src) != nil {
+					now, _ = after.For(src).Value(v.Key)
+				}
+				if now == old {
+					continue
+				}
+				restore.Set(src, v.Key, old)
+				rbErr.RolledBack = append(rbErr.RolledBack, Change{
+					Source: src,
+					Key:    v.Key,
+					Before: now,
+					After:  old,
+					Known:  true,
+				})
+			}
+		}
+	}
+
+	for _, cmd := range commands(restore.Invocations(), true) {
+		if err := runner.Default.Run(cmd[0], cmd[1:]...); err != nil {
+			rbErr.RestoreErr = err
+			break
+		}
+	}
+	return rbErr
+}
+
+// StepError reports which pmset invocation of a plan failed
+type StepError struct {
+	Invocation Invocation
+	Err        error
+}
+
+func (e *StepError) Error() string {
+	return "failed to apply " + e.Invocation.Label() + " settings: " + e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// RollbackError is returned when a plan failed part-way and was rolled back
+type RollbackError struct {
+	Err        error    // the failure that triggered the rollback
+	RolledBack []Change // keys restored, from their value after the failure (Before) to the snapshot (After)
+	RestoreErr error    // non-nil if restoring the snapshot also failed
+}
+
+func (e *RollbackError) Error() string {
+	msg := e.Err.Error()
+	if e.RestoreErr != nil {
+		return msg + "; rollback failed: " + e.RestoreErr.Error()
+	}
+	return fmt.Sprintf("%s (rolled back %d settings)", msg, len(e.RolledBack))
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
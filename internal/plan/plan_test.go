@@ -0,0 +1,238 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/born1337/macpwr/internal/runner"
+	"github.com/born1337/macpwr/internal/settings"
+)
+
+// fakePmset simulates pmset: it serves pmset -g custom from its state and
+// applies sudo pmset calls to it, failing any call containing failOn
+type fakePmset struct {
+	state  map[string]map[string]int // section header -> key -> value
+	failOn string
+	calls  []string
+}
+
+func newFakePmset() *fakePmset {
+	return &fakePmset{state: map[string]map[string]int{
+		"Battery Power:": {"displaysleep": 2, "sleep": 1, "disksleep": 10},
+		"AC Power:":      {"displaysleep": 10, "sleep": 30, "disksleep": 10},
+	}}
+}
+
+func (f *fakePmset) Output(name string, args ...string) ([]byte, error) {
+	var b strings.Builder
+	for _, header := range []string{"Battery Power:", "AC Power:"} {
+		fmt.Fprintln(&b, header)
+		var keys []string
+		for k := range f.state[header] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %-20s %d\n", k, f.state[header][k])
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+func (f *fakePmset) Run(name string, args ...string) error {
+	line := strings.Join(append([]string{name}, args...), " ")
+	f.calls = append(f.calls, line)
+	if f.failOn != "" && strings.Contains(line, f.failOn) {
+		return errors.New("exit status 1")
+	}
+
+	for i, a := range args {
+		if a != "pmset" {
+			continue
+		}
+		headers := map[string][]string{
+			"-a": {"Battery Power:", "AC Power:"},
+			"-b": {"Battery Power:"},
+			"-c": {"AC Power:"},
+		}[args[i+1]]
+		for j := i + 2; j+1 < len(args); j += 2 {
+			val, _ := strconv.Atoi(args[j+1])
+			for _, h := range headers {
+				f.state[h][args[j]] = val
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakePmset) Start(ctx context.Context, name string, args ...string) (runner.Process, error) {
+	return nil, errors.New("not supported")
+}
+
+func withRunner(t *testing.T, r runner.Runner) {
+	orig := runner.Default
+	runner.Default = r
+	t.Cleanup(func() { runner.Default = orig })
+}
+
+func current(t *testing.T) *settings.AllSettings {
+	t.Helper()
+	s, err := settings.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func joined(cmds [][]string) []string {
+	var lines []string
+	for _, c := range cmds {
+		lines = append(lines, strings.Join(c, " "))
+	}
+	return lines
+}
+
+func TestInvocations(t *testing.T) {
+	withRunner(t, newFakePmset())
+
+	tests := []struct {
+		name string
+		set  func(p *Plan)
+		want []string
+	}{
+		{
+			name: "single source",
+			set: func(p *Plan) {
+				p.Set(settings.Battery, "displaysleep", 5)
+				p.Set(settings.Battery, "sleep", 1) // already in place
+			},
+			want: []string{"sudo pmset -b displaysleep 5"},
+		},
+		{
+			name: "identical values merge into -a",
+			set: func(p *Plan) {
+				for _, src := range []settings.Source{settings.Battery, settings.AC} {
+					p.Set(src, "displaysleep", 0)
+					p.Set(src, "sleep", 0)
+				}
+			},
+			want: []string{"sudo pmset -a displaysleep 0 sleep 0"},
+		},
+		{
+			name: "mixed values keep per-source calls",
+			set: func(p *Plan) {
+				p.Set(settings.Battery, "displaysleep", 1)
+				p.Set(settings.Battery, "disksleep", 5)
+				p.Set(settings.AC, "displaysleep", 5)
+				p.Set(settings.AC, "disksleep", 5)
+			},
+			want: []string{
+				"sudo -v",
+				"sudo -n pmset -b displaysleep 1 disksleep 5",
+				"sudo -n pmset -c displaysleep 5 disksleep 5",
+			},
+		},
+		{
+			name: "nothing to change",
+			set: func(p *Plan) {
+				p.Set(settings.AC, "sleep", 30)
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(current(t))
+			tt.set(p)
+			got := joined(p.Commands())
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Commands() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestExecuteRollsBack(t *testing.T) {
+	fake := newFakePmset()
+	fake.failOn = "-c displaysleep 5"
+	withRunner(t, fake)
+
+	p := New(current(t))
+	p.Set(settings.Battery, "displaysleep", 1)
+	p.Set(settings.Battery, "sleep", 2)
+	p.Set(settings.AC, "displaysleep", 5)
+
+	err := p.Execute()
+
+	var rb *RollbackError
+	if !errors.As(err, &rb) {
+		t.Fatalf("Execute() error = %v, want *RollbackError", err)
+	}
+	if rb.RestoreErr != nil {
+		t.Errorf("RestoreErr = %v, want nil", rb.RestoreErr)
+	}
+	if len(rb.RolledBack) != 2 {
+		t.Errorf("RolledBack = %+v, want the two battery keys", rb.RolledBack)
+	}
+
+	want := []string{
+		"sudo -v",
+		"sudo -n pmset -b displaysleep 1 sleep 2",
+		"sudo -n pmset -c displaysleep 5",
+		"sudo -n pmset -b displaysleep 2 sleep 1",
+	}
+	if strings.Join(fake.calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls =\n%s\nwant\n%s", strings.Join(fake.calls, "\n"), strings.Join(want, "\n"))
+	}
+	if fake.state["Battery Power:"]["displaysleep"] != 2 {
+		t.Errorf("battery displaysleep not restored: %v", fake.state["Battery Power:"])
+	}
+}
+
+func TestValidate(t *testing.T) {
+	withRunner(t, newFakePmset())
+
+	p := New(current(t))
+	p.Set(settings.Battery, "displaysleep", 5) // battery sleeps after 1 min
+	p.Set(settings.AC, "hibernatemode", 1)
+	p.Set(settings.AC, "disksleep", -1)
+
+	err := p.Validate()
+	var verr *settings.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() = %v, want *settings.ValidationError", err)
+	}
+	if len(verr.Problems) != 3 {
+		t.Errorf("Problems = %q, want 3", verr.Problems)
+	}
+
+	fake := newFakePmset()
+	withRunner(t, fake)
+	p = New(current(t))
+	p.Set(settings.AC, "hibernatemode", 1)
+	if err := p.Execute(); err == nil || len(fake.calls) != 0 {
+		t.Errorf("Execute() = %v with calls %q; invalid plans must not run", err, fake.calls)
+	}
+}
+
+func TestExecuteSucceeds(t *testing.T) {
+	fake := newFakePmset()
+	withRunner(t, fake)
+
+	p := New(nil)
+	p.Set(settings.AC, "sleep", 0)
+	p.Set(settings.AC, "sleep", 30)
+
+	if err := p.Execute(); err != nil {
+		t.Fatalf("Execute() = %v", err)
+	}
+	if len(fake.calls) != 1 || fake.calls[0] != "sudo pmset -c sleep 30" {
+		t.Errorf("calls = %q", fake.calls)
+	}
+}
@@ -0,0 +1,195 @@
+package plist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dict is a decoded <dict>
+type Dict map[string]any
+
+// Parse decodes an XML property list, such as the output of ioreg -a.
+// Values decode to Dict, []any, int64, float64, bool, string, []byte and
+// time.Time.
+func Parse(data []byte) (any, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no plist element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == "plist" {
+			v, err := value(d, nil)
+			if err == errEnd {
+				return nil, nil // empty plist
+			}
+			return v, err
+		}
+	}
+}
+
+// errEnd reports the end element of the enclosing array or plist
+var errEnd = fmt.Errorf("end of container")
+
+// value decodes the next value. start is its start element, or nil to read
+// it from the decoder.
+func value(d *xml.Decoder, start *xml.StartElement) (any, error) {
+	for start == nil {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			start = &t
+		case xml.EndElement:
+			return nil, errEnd
+		}
+	}
+
+	switch start.Name.Local {
+	case "dict":
+		return dict(d)
+	case "array":
+		var arr []any
+		for {
+			v, err := value(d, nil)
+			if err == errEnd {
+				return arr, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+	case "true", "false":
+		if err := d.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := d.DecodeElement(&text, start); err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		return parseInteger(text)
+	case "real":
+		return strconv.ParseFloat(text, 64)
+	case "data":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	case "date":
+		return time.Parse(time.RFC3339, text)
+	}
+	return nil, fmt.Errorf("unsupported plist element <%s>", start.Name.Local)
+}
+
+// parseInteger parses a plist integer. IOKit writes negative values as
+// unsigned 64-bit integers, so those wrap back to their signed value.
+func parseInteger(text string) (int64, error) {
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, nil
+	}
+	u, err := strconv.ParseUint(text, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(u), nil
+}
+
+func dict(d *xml.Decoder) (Dict, error) {
+	m := make(Dict)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return m, nil
+		case xml.StartElement:
+			if t.Name.Local != "key" {
+				return nil, fmt.Errorf("expected <key> in dict, got <%s>", t.Name.Local)
+			}
+			var key string
+			if err := d.DecodeElement(&key, &t); err != nil {
+				return nil, err
+			}
+			v, err := value(d, nil)
+			if err != nil {
+				if err == errEnd {
+					err = fmt.Errorf("no value for key %q", key)
+				}
+				return nil, err
+			}
+			m[key] = v
+		}
+	}
+}
+
+// Int returns an integer value, or 0 if the key is missing or not a number
+func (m Dict) Int(key string) int {
+	switch v := m[key].(type) {
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// Int64 returns an integer value without narrowing it
+func (m Dict) Int64(key string) int64 {
+	v, _ := m[key].(int64)
+	return v
+}
+
+// Bool returns a boolean value, or false if the key is missing
+func (m Dict) Bool(key string) bool {
+	v, _ := m[key].(bool)
+	return v
+}
+
+// String returns a string value, or "" if the key is missing
+func (m Dict) String(key string) string {
+	v, _ := m[key].(string)
+	return v
+}
+
+// Dict returns a nested dictionary, or nil if the key is missing
+func (m Dict) Dict(key string) Dict {
+	v, _ := m[key].(Dict)
+	return v
+}
+
+// Array returns an array value, or nil if the key is missing
+func (m Dict) Array(key string) []any {
+	v, _ := m[key].([]any)
+	return v
+}
+
+// Ints returns an array of integers, skipping other values
+func (m Dict) Ints(key string) []int {
+	var ints []int
+	for _, v := range m.Array(key) {
+		if n, ok := v.(int64); ok {
+			ints = append(ints, int(n))
+		}
+	}
+	return ints
+}
@@ -0,0 +1,101 @@
+package plist
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<array>
+	<dict>
+		<key>Amperage</key>
+		<integer>18446744073709550616</integer>
+		<key>DeviceName</key>
+		<string>bq40z651</string>
+		<key>ExternalConnected</key>
+		<true/>
+		<key>FullyCharged</key>
+		<false/>
+		<key>Qmax</key>
+		<array>
+			<integer>4210</integer>
+			<integer>4198</integer>
+		</array>
+		<key>AdapterDetails</key>
+		<dict>
+			<key>Watts</key>
+			<integer>67</integer>
+			<key>Name</key>
+			<string>67W USB-C Power Adapter</string>
+		</dict>
+		<key>Empty</key>
+		<dict/>
+		<key>Ratio</key>
+		<real>0.5</real>
+		<key>Blob</key>
+		<data>
+		AQID
+		</data>
+		<key>Updated</key>
+		<date>2026-10-16T08:00:00Z</date>
+	</dict>
+</array>
+</plist>
+`)
+
+	v, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	arr, ok := v.([]any)
+	if !ok || len(arr) != 1 {
+		t.Fatalf("Parse() = %#v, want a one-element array", v)
+	}
+	d, ok := arr[0].(Dict)
+	if !ok {
+		t.Fatalf("element is %T, want Dict", arr[0])
+	}
+
+	if got := d.Int("Amperage"); got != -1000 {
+		t.Errorf("Amperage = %d, want -1000", got)
+	}
+	if got := d.String("DeviceName"); got != "bq40z651" {
+		t.Errorf("DeviceName = %q", got)
+	}
+	if !d.Bool("ExternalConnected") || d.Bool("FullyCharged") {
+		t.Errorf("booleans decoded wrong: %v %v", d["ExternalConnected"], d["FullyCharged"])
+	}
+	if got := d.Ints("Qmax"); !reflect.DeepEqual(got, []int{4210, 4198}) {
+		t.Errorf("Qmax = %v", got)
+	}
+	if got := d.Dict("AdapterDetails").Int("Watts"); got != 67 {
+		t.Errorf("AdapterDetails.Watts = %d, want 67", got)
+	}
+	if got := d.Dict("Empty"); got == nil || len(got) != 0 {
+		t.Errorf("Empty = %#v, want an empty Dict", got)
+	}
+	if got := d["Ratio"]; got != 0.5 {
+		t.Errorf("Ratio = %v", got)
+	}
+	if got := d["Blob"]; !reflect.DeepEqual(got, []byte{1, 2, 3}) {
+		t.Errorf("Blob = %v", got)
+	}
+	if got := d["Updated"]; got != time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC) {
+		t.Errorf("Updated = %v", got)
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	v, err := Parse([]byte(`<plist version="1.0"></plist>`))
+	if err != nil || v != nil {
+		t.Errorf("Parse(empty) = %v, %v, want nil, nil", v, err)
+	}
+
+	if _, err := Parse([]byte("not a plist")); err == nil {
+		t.Errorf("Parse(garbage) returned no error")
+	}
+}
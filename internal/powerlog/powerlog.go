@@ -0,0 +1,271 @@
+package powerlog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/born1337/macpwr/internal/runner"
+)
+
+// EventType is the kind of a power log event
+type EventType string
+
+const (
+	Sleep     EventType = "sleep"
+	Wake      EventType = "wake"
+	DarkWake  EventType = "darkwake"
+	Assertion EventType = "assertion"
+)
+
+// EventTypes lists the event types macpwr parses
+var EventTypes = []EventType{Sleep, Wake, DarkWake, Assertion}
+
+// ParseEventType parses an event type name such as "darkwake"
+func ParseEventType(name string) (EventType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "assertions" {
+		return Assertion, nil
+	}
+	for _, t := range EventTypes {
+		if name == string(t) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown event type %q (use sleep, wake, darkwake or assertion)", name)
+}
+
+// Label returns the event type as pmset names its domain
+func (t EventType) Label() string {
+	switch t {
+	case Sleep:
+		return "Sleep"
+	case Wake:
+		return "Wake"
+	case DarkWake:
+		return "DarkWake"
+	case Assertion:
+		return "Assertion"
+	}
+	return string(t)
+}
+
+// Event is one Sleep, Wake, DarkWake or Assertions entry from pmset -g log
+type Event struct {
+	Time     time.Time
+	Type     EventType
+	Message  string
+	Reason   string        // sleep reason ("Idle Sleep") or wake reason ("EC.LidOpen/Lid Open")
+	Duration time.Duration // time asleep, awake in dark wake, or an assertion's age
+	Source   string        // power source at the time: "AC" or "Battery"
+	Charge   int           // battery percentage, -1 if not logged
+
+	// Assertion events only
+	PID           int
+	Process       string
+	Action        string // Created, Released, TimedOut, ...
+	AssertionType string
+	Name          string
+}
+
+// Get reads and parses the power management log
+func Get() ([]Event, error) {
+	output, err := runner.Default.Output("pmset", "-g", "log")
+	if err != nil {
+		return nil, err
+	}
+	return Parse(string(output)), nil
+}
+
+var (
+	logLine       = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2} [+-]\d{4}) (.+?)(?:\t|\s{2,})\s*(.*)$`)
+	secsSuffix    = regexp.MustCompile(`\s+(\d+) secs?\s*$`)
+	usingSource   = regexp.MustCompile(`\s*Using (AC|BATT|Batt)(?: \(Charge:\s*(\d+)%\))?`)
+	sleepReason   = regexp.MustCompile(`due to '([^']+)'`)
+	wakeReason    = regexp.MustCompile(`due to (.+)$`)
+	assertionLine = regexp.MustCompile(`^PID (\d+)\(([^)]*)\) (\w+) (\w+) "([^"]*)"\s*(?:(\d{2}):(\d{2}):(\d{2}))?`)
+)
+
+// Parse parses the output of pmset -g log, keeping Sleep, Wake, DarkWake
+// and Assertions entries in log order
+func Parse(data string) []Event {
+	var events []Event
+	for _, line := range strings.Split(data, "\n") {
+		m := logLine.FindStringSubmatch(strings.TrimRight(line, " \t"))
+		if m == nil {
+			continue
+		}
+
+		t, err := time.Parse("2006-01-02 15:04:05 -0700", m[1])
+		if err != nil {
+			continue
+		}
+		e := Event{Time: t, Charge: -1}
+		switch strings.TrimSpace(m[2]) {
+		case "Sleep":
+			e.Type = Sleep
+		case "Wake":
+			e.Type = Wake
+		case "DarkWake":
+			e.Type = DarkWake
+		case "Assertions":
+			e.Type = Assertion
+		default:
+			continue
+		}
+
+		msg := m[3]
+		if d := secsSuffix.FindStringSubmatch(msg); d != nil {
+			secs, _ := strconv.Atoi(d[1])
+			e.Duration = time.Duration(secs) * time.Second
+			msg = msg[:len(msg)-len(d[0])]
+		}
+		if u := usingSource.FindStringSubmatch(msg); u != nil {
+			e.Source = "AC"
+			if u[1] != "AC" {
+				e.Source = "Battery"
+			}
+			if u[2] != "" {
+				e.Charge, _ = strconv.Atoi(u[2])
+			}
+			msg = strings.Replace(msg, u[0], "", 1)
+		}
+		e.Message = strings.TrimSpace(msg)
+
+		switch e.Type {
+		case Sleep:
+			if r := sleepReason.FindStringSubmatch(e.Message); r != nil {
+				e.Reason = r[1]
+			}
+		case Wake, DarkWake:
+			if r := wakeReason.FindStringSubmatch(e.Message); r != nil {
+				e.Reason = strings.TrimSpace(r[1])
+			}
+		case Assertion:
+			parseAssertion(&e)
+		}
+		events = append(events, e)
+	}
+
+	fillSleepDurations(events)
+	return events
+}
+
+func parseAssertion(e *Event) {
+	a := assertionLine.FindStringSubmatch(e.Message)
+	if a == nil {
+		return
+	}
+	e.PID, _ = strconv.Atoi(a[1])
+	e.Process = a[2]
+	e.Action = a[3]
+	e.AssertionType = a[4]
+	e.Name = a[5]
+	if a[6] != "" {
+		h, _ := strconv.Atoi(a[6])
+		m, _ := strconv.Atoi(a[7])
+		s, _ := strconv.Atoi(a[8])
+		e.Duration = time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+	}
+}
+
+// fillSleepDurations sets the duration of sleeps pmset logged without one
+// to the time until the next wake or dark wake
+func fillSleepDurations(events []Event) {
+	for i := range events {
+		if events[i].Type != Sleep || events[i].Duration != 0 {
+			continue
+		}
+		for _, next := range events[i+1:] {
+			if next.Type == Wake || next.Type == DarkWake {
+				events[i].Duration = next.Time.Sub(events[i].Time)
+				break
+			}
+		}
+	}
+}
+
+// Filter selects events by time range and type
+type Filter struct {
+	Since time.Time // zero = no lower bound
+	Until time.Time // zero = no upper bound
+	Types []EventType
+}
+
+// Apply returns the events matching the filter
+func (f Filter) Apply(events []Event) []Event {
+	var out []Event
+	for _, e := range events {
+		if !f.Since.IsZero() && e.Time.Before(f.Since) {
+			continue
+		}
+		if !f.Until.IsZero() && e.Time.After(f.Until) {
+			continue
+		}
+		if len(f.Types) > 0 && !containsType(f.Types, e.Type) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func containsType(types []EventType, t EventType) bool {
+	for _, x := range types {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary totals a set of events
+type Summary struct {
+	Sleeps     int
+	Wakes      int
+	DarkWakes  int
+	Assertions int
+	TotalSleep time.Duration
+}
+
+// Summarize counts events and totals the time spent asleep
+func Summarize(events []Event) Summary {
+	var s Summary
+	for _, e := range events {
+		switch e.Type {
+		case Sleep:
+			s.Sleeps++
+			s.TotalSleep += e.Duration
+		case Wake:
+			s.Wakes++
+		case DarkWake:
+			s.DarkWakes++
+		case Assertion:
+			s.Assertions++
+		}
+	}
+	return s
+}
+
+// ParseTime parses a --since or --until value relative to now: a duration
+// ago ("90m", "24h", "7d"), a date ("2006-01-02") or a date and time
+// ("2006-01-02 15:04")
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use a duration such as 24h or 7d, or YYYY-MM-DD [HH:MM])", s)
+}
@@ -0,0 +1,151 @@
+package powerlog
+
+import (
+	"testing"
+	"time"
+)
+
+const sample = `Time stamp                Domain                	Message                                                                    	Duration  	Delay
+==========                ======                	=======                                                                    	========  	=====
+2026-10-14 19:05:30 +0200 Sleep               	Entering Sleep state due to 'Clamshell Sleep':TCPKeepAlive=active Using BATT (Charge:64%)	9120 secs 
+2026-10-14 21:37:30 +0200 DarkWake            	DarkWake from Deep Idle [CDN] : due to RTC/Maintenance Using BATT (Charge:63%)	45 secs   
+2026-10-14 21:38:15 +0200 Sleep               	Entering Sleep state due to 'Maintenance Sleep':TCPKeepAlive=active Using AC
+2026-10-15 07:09:30 +0200 Wake                	Wake from Deep Idle [CDNVA] : due to EC.LidOpen/Lid Open Using BATT (Charge:55%)            
+2026-10-15 07:09:31 +0200 Wake Requests       	[process=mDNSResponder request=Maintenance deltaSecs=7200 wakeAt=2026-10-15 09:09:31]
+2026-10-15 07:10:02 +0200 Assertions          	PID 873(zoom.us) Released PreventUserIdleDisplaySleep "Zoom is in a meeting" 01:12:40  id:0x0xd0000931d [System: PrevIdle DeclUser kDisp]
+`
+
+func TestParse(t *testing.T) {
+	events := Parse(sample)
+	if len(events) != 5 {
+		t.Fatalf("Parse returned %d events, want 5: %+v", len(events), events)
+	}
+
+	sleep := events[0]
+	if sleep.Type != Sleep || sleep.Reason != "Clamshell Sleep" || sleep.Duration != 9120*time.Second ||
+		sleep.Source != "Battery" || sleep.Charge != 64 {
+		t.Errorf("sleep = %+v", sleep)
+	}
+	if got := sleep.Time.Format(time.RFC3339); got != "2026-10-14T19:05:30+02:00" {
+		t.Errorf("sleep.Time = %s", got)
+	}
+
+	dark := events[1]
+	if dark.Type != DarkWake || dark.Reason != "RTC/Maintenance" || dark.Duration != 45*time.Second {
+		t.Errorf("dark wake = %+v", dark)
+	}
+
+	// A sleep logged without a duration lasts until the next wake
+	if events[2].Duration != 9*time.Hour+31*time.Minute+15*time.Second || events[2].Charge != -1 || events[2].Source != "AC" {
+		t.Errorf("open sleep = %+v", events[2])
+	}
+
+	if wake := events[3]; wake.Type != Wake || wake.Reason != "EC.LidOpen/Lid Open" {
+		t.Errorf("wake = %+v", wake)
+	}
+
+	a := events[4]
+	if a.Type != Assertion || a.PID != 873 || a.Process != "zoom.us" || a.Action != "Released" ||
+		a.AssertionType != "PreventUserIdleDisplaySleep" || a.Name != "Zoom is in a meeting" ||
+		a.Duration != time.Hour+12*time.Minute+40*time.Second {
+		t.Errorf("assertion = %+v", a)
+	}
+}
+
+func TestFilterAndSummarize(t *testing.T) {
+	events := Parse(sample)
+	since, _ := time.Parse(time.RFC3339, "2026-10-14T21:00:00+02:00")
+
+	got := Filter{Since: since, Types: []EventType{Sleep, Wake, DarkWake}}.Apply(events)
+	if len(got) != 3 {
+		t.Fatalf("Apply returned %d events, want 3", len(got))
+	}
+
+	s := Summarize(got)
+	want := Summary{Sleeps: 1, Wakes: 1, DarkWakes: 1, TotalSleep: 9*time.Hour + 31*time.Minute + 15*time.Second}
+	if s != want {
+		t.Errorf("Summarize = %+v, want %+v", s, want)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"24h", now.Add(-24 * time.Hour), false},
+		{"7d", now.AddDate(0, 0, -7), false},
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), false},
+		{"2026-10-01 08:30", time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC), false},
+		{"yesterday", time.Time{}, true},
+		{"-5d", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTime(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   WakeCause
+	}{
+		{"EC.LidOpen/Lid Open", CauseLid},
+		{"RTC/Alarm", CauseScheduled},
+		{"RTC/Maintenance", CauseRTC},
+		{"SMC.OutboxNotEmpty smc.70070000 wifibt/", CauseNetwork},
+		{"ARPT/Network", CauseNetwork},
+		{"XHC1/UserActivity Assertion", CauseUSB},
+		{"BT/HID Activity", CauseBluetooth},
+		{"EC.PowerButton/Power Button", CausePowerButton},
+		{"SPU/UserActivity", CauseUserActivity},
+		{"NUB.SPMISw3IRQ nub-spmi0.0x02", CauseOther},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.reason); got != tt.want {
+			t.Errorf("Classify(%q) = %s, want %s", tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeWakes(t *testing.T) {
+	events := []Event{
+		{Type: DarkWake, Reason: "RTC/Maintenance"},
+		{Type: Sleep, Reason: "Maintenance Sleep"},
+		{Type: DarkWake, Reason: "SMC.OutboxNotEmpty smc.70070000 wifibt/"},
+		{Type: Wake, Reason: "ARPT/Network"},
+		{Type: Wake, Reason: "EC.LidOpen/Lid Open"},
+		{Type: DarkWake, Reason: "RTC/Maintenance"},
+	}
+
+	r := AnalyzeWakes(events)
+	if r.Wakes != 2 || r.DarkWakes != 3 || r.PowerNap != 2 {
+		t.Errorf("report = %+v", r)
+	}
+	if r.UnexpectedTotal() != 4 {
+		t.Errorf("UnexpectedTotal = %d, want 4", r.UnexpectedTotal())
+	}
+
+	top := r.Unexpected()
+	if len(top) != 2 || top[0].Cause != CauseRTC || top[1].Cause != CauseNetwork {
+		t.Fatalf("Unexpected = %+v", top)
+	}
+	if top[1].Wakes != 1 || top[1].DarkWakes != 1 || len(top[1].Reasons) != 2 {
+		t.Errorf("network = %+v", top[1])
+	}
+	if len(top[0].Reasons) != 1 {
+		t.Errorf("RTC reasons = %q, want one distinct reason", top[0].Reasons)
+	}
+}
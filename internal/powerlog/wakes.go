@@ -0,0 +1,158 @@
+package powerlog
+
+import (
+	"sort"
+	"strings"
+)
+
+// WakeCause is a category of wake reason
+type WakeCause string
+
+const (
+	CauseLid          WakeCause = "Lid Open"
+	CausePowerButton  WakeCause = "Power Button"
+	CauseUserActivity WakeCause = "User Activity"
+	CauseScheduled    WakeCause = "Scheduled"
+	CauseRTC          WakeCause = "RTC"
+	CauseNetwork      WakeCause = "Network"
+	CauseUSB          WakeCause = "USB"
+	CauseBluetooth    WakeCause = "Bluetooth"
+	CausePowerAdapter WakeCause = "Power Adapter"
+	CauseOther        WakeCause = "Other"
+)
+
+// causeMatchers map substrings of pmset wake reasons to causes, checked in
+// order so that the more specific device wins ("XHC1/UserActivity" is USB)
+var causeMatchers = []struct {
+	cause    WakeCause
+	patterns []string
+}{
+	{CauseLid, []string{"lidopen", "lid open"}},
+	{CausePowerButton, []string{"powerbutton", "pwrb"}},
+	{CauseScheduled, []string{"rtc/alarm", "scheduled"}},
+	{CauseNetwork, []string{"wifibt", "arpt", "network", "en0", "en1", "wol", "magic packet"}},
+	{CauseBluetooth, []string{"bt/", "bluetooth"}},
+	{CauseUSB, []string{"xhc", "usb", "ehc", "ohc"}},
+	{CauseRTC, []string{"rtc"}},
+	{CausePowerAdapter, []string{"acattach", "acdetach", "ac attach", "charger"}},
+	{CauseUserActivity, []string{"useractivity", "keyboard", "trackpad", "hid"}},
+}
+
+// Classify returns the cause category of a pmset wake reason
+func Classify(reason string) WakeCause {
+	r := strings.ToLower(reason)
+	for _, m := range causeMatchers {
+		for _, p := range m.patterns {
+			if strings.Contains(r, p) {
+				return m.cause
+			}
+		}
+	}
+	return CauseOther
+}
+
+// Expected reports whether wakes with this cause are normally intended:
+// the user opened the lid, pressed a key or button, or scheduled the wake
+func (c WakeCause) Expected() bool {
+	switch c {
+	case CauseLid, CausePowerButton, CauseUserActivity, CauseScheduled:
+		return true
+	}
+	return false
+}
+
+// IsPowerNap reports whether a dark wake was a Power Nap maintenance wake
+func IsPowerNap(e Event) bool {
+	return e.Type == DarkWake && strings.Contains(strings.ToLower(e.Reason), "maintenance")
+}
+
+// CauseCount counts the wakes and dark wakes with one cause
+type CauseCount struct {
+	Cause     WakeCause
+	Wakes     int
+	DarkWakes int
+	Reasons   []string // distinct raw reasons, in first-seen order
+}
+
+// Total returns the number of wakes and dark wakes
+func (c CauseCount) Total() int {
+	return c.Wakes + c.DarkWakes
+}
+
+// WakeReport aggregates the wake reasons in a set of events
+type WakeReport struct {
+	Wakes     int
+	DarkWakes int
+	PowerNap  int // dark wakes attributed to Power Nap
+	Causes    []CauseCount
+}
+
+// Unexpected returns the causes of unexpected wakes, most frequent first
+func (r WakeReport) Unexpected() []CauseCount {
+	var out []CauseCount
+	for _, c := range r.Causes {
+		if !c.Cause.Expected() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// UnexpectedTotal returns the number of unexpected wakes and dark wakes
+func (r WakeReport) UnexpectedTotal() int {
+	n := 0
+	for _, c := range r.Unexpected() {
+		n += c.Total()
+	}
+	return n
+}
+
+// AnalyzeWakes counts wakes and dark wakes by cause, ranking causes by
+// how often they occurred
+func AnalyzeWakes(events []Event) WakeReport {
+	var r WakeReport
+	index := make(map[WakeCause]int)
+
+	for _, e := range events {
+		if e.Type != Wake && e.Type != DarkWake {
+			continue
+		}
+
+		cause := Classify(e.Reason)
+		i, ok := index[cause]
+		if !ok {
+			i = len(r.Causes)
+			index[cause] = i
+			r.Causes = append(r.Causes, CauseCount{Cause: cause})
+		}
+		c := &r.Causes[i]
+
+		if e.Type == Wake {
+			r.Wakes++
+			c.Wakes++
+		} else {
+			r.DarkWakes++
+			c.DarkWakes++
+			if IsPowerNap(e) {
+				r.PowerNap++
+			}
+		}
+		if e.Reason != "" && !containsString(c.Reasons, e.Reason) {
+			c.Reasons = append(c.Reasons, e.Reason)
+		}
+	}
+
+	sort.SliceStable(r.Causes, func(i, j int) bool {
+		return r.Causes[i].Total() > r.Causes[j].Total()
+	})
+	return r
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package powersource
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/runner"
+)
+
+// State is the charging state pmset reports for a power source
+type State string
+
+const (
+	Charging        State = "charging"
+	Discharging     State = "discharging"
+	Charged         State = "charged"
+	FinishingCharge State = "finishing charge"
+	NotCharging     State = "not charging" // AC attached but held below full
+	Unknown         State = ""
+)
+
+// Label returns the state in title case, as shown by macpwr
+func (s State) Label() string {
+	switch s {
+	case Charging:
+		return "Charging"
+	case Discharging:
+		return "Discharging"
+	case Charged:
+		return "Charged"
+	case FinishingCharge:
+		return "Finishing Charge"
+	case NotCharging:
+		return "Not Charging"
+	}
+	return "Unknown"
+}
+
+// Source is one battery or UPS listed by pmset -g ps
+type Source struct {
+	Name          string // "InternalBattery-0", or the UPS model name
+	ID            int
+	Percent       int
+	State         State
+	TimeRemaining int // minutes, -1 if pmset has no estimate
+	Present       bool
+}
+
+// Internal reports whether the source is the Mac's own battery
+func (s *Source) Internal() bool {
+	return strings.HasPrefix(s.Name, "InternalBattery")
+}
+
+// Charging reports whether the source is taking charge
+func (s *Source) Charging() bool {
+	return s.State == Charging || s.State == FinishingCharge
+}
+
+// Status is the parsed output of pmset -g ps
+type Status struct {
+	Drawing string // "AC Power", "Battery Power" or "UPS Power"
+	Sources []Source
+}
+
+// OnAC reports whether the Mac is drawing from AC power
+func (s *Status) OnAC() bool {
+	return s.Drawing == "AC Power"
+}
+
+// OnBattery reports whether the Mac is drawing from its internal battery
+func (s *Status) OnBattery() bool {
+	return s.Drawing == "Battery Power"
+}
+
+// OnUPS reports whether the Mac is drawing from a UPS
+func (s *Status) OnUPS() bool {
+	return s.Drawing == "UPS Power"
+}
+
+// Label returns a short name for the active power source
+func (s *Status) Label() string {
+	switch {
+	case s.OnAC():
+		return "AC Power"
+	case s.OnBattery():
+		return "Battery"
+	case s.OnUPS():
+		return "UPS"
+	}
+	return "Unknown"
+}
+
+// InternalBattery returns the Mac's own battery, or nil on desktops
+func (s *Status) InternalBattery() *Source {
+	for i := range s.Sources {
+		if s.Sources[i].Internal() {
+			return &s.Sources[i]
+		}
+	}
+	return nil
+}
+
+// UPS returns the first attached UPS, or nil if there is none
+func (s *Status) UPS() *Source {
+	for i := range s.Sources {
+		if !s.Sources[i].Internal() {
+			return &s.Sources[i]
+		}
+	}
+	return nil
+}
+
+// Get reads the current power source status
+func Get() (*Status, error) {
+	output, err := runner.Default.Output("pmset", "-g", "ps")
+	if err != nil {
+		return nil, err
+	}
+	return parse(string(output))
+}
+
+var (
+	drawingLine   = regexp.MustCompile(`Now drawing from '([^']+)'`)
+	sourceLine    = regexp.MustCompile(`^-(.+?) \(id=(\d+)\)\s+(\d+)%;\s*(.*)$`)
+	remainingTime = regexp.MustCompile(`(\d+):(\d{2}) remaining`)
+	presentFlag   = regexp.MustCompile(`present: (true|false)`)
+)
+
+func parse(data string) (*Status, error) {
+	m := drawingLine.FindStringSubmatch(data)
+	if m == nil {
+		return nil, fmt.Errorf("unexpected pmset output format")
+	}
+	s := &Status{Drawing: m[1]}
+
+	for _, line := range strings.Split(data, "\n") {
+		m := sourceLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+
+		src := Source{Name: m[1], TimeRemaining: -1}
+		src.ID, _ = strconv.Atoi(m[2])
+		src.Percent, _ = strconv.Atoi(m[3])
+		src.State = parseState(m[4])
+
+		if t := remainingTime.FindStringSubmatch(m[4]); t != nil {
+			h, _ := strconv.Atoi(t[1])
+			min, _ := strconv.Atoi(t[2])
+			src.TimeRemaining = h*60 + min
+		}
+		if p := presentFlag.FindStringSubmatch(m[4]); p != nil {
+			src.Present = p[1] == "true"
+		} else {
+			src.Present = true
+		}
+		s.Sources = append(s.Sources, src)
+	}
+	return s, nil
+}
+
+// parseState reads the state from the fields after the percentage, such as
+// "charging; 0:42 remaining" or "AC attached; not charging"
+func parseState(rest string) State {
+	fields := strings.Split(rest, ";")
+	state := strings.TrimSpace(fields[0])
+	switch State(state) {
+	case Charging, Discharging, Charged, FinishingCharge:
+		return State(state)
+	}
+	if strings.Contains(rest, "not charging") {
+		return NotCharging
+	}
+	return Unknown
+}
@@ -0,0 +1,71 @@
+package powersource
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	data := `Now drawing from 'UPS Power'
+ -InternalBattery-0 (id=23527523)	95%; finishing charge; 0:05 remaining present: true
+ -CP1500PFCLCDa (id=1234)	80%; discharging; (no estimate) present: true
+`
+	s, err := parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.OnUPS() || s.Label() != "UPS" {
+		t.Errorf("Drawing = %q, want UPS Power", s.Drawing)
+	}
+	if len(s.Sources) != 2 {
+		t.Fatalf("Sources = %+v, want 2", s.Sources)
+	}
+
+	b := s.InternalBattery()
+	want := Source{Name: "InternalBattery-0", ID: 23527523, Percent: 95, State: FinishingCharge, TimeRemaining: 5, Present: true}
+	if b == nil || *b != want {
+		t.Errorf("InternalBattery = %+v, want %+v", b, want)
+	}
+	if !b.Charging() {
+		t.Errorf("finishing charge should count as charging")
+	}
+
+	u := s.UPS()
+	want = Source{Name: "CP1500PFCLCDa", ID: 1234, Percent: 80, State: Discharging, TimeRemaining: -1, Present: true}
+	if u == nil || *u != want {
+		t.Errorf("UPS = %+v, want %+v", u, want)
+	}
+}
+
+func TestParseStates(t *testing.T) {
+	tests := []struct {
+		line string
+		want State
+	}{
+		{" -InternalBattery-0 (id=1)	87%; charging; 0:42 remaining present: true", Charging},
+		{" -InternalBattery-0 (id=1)	100%; charged; 0:00 remaining present: true", Charged},
+		{" -InternalBattery-0 (id=1)	80%; AC attached; not charging present: true", NotCharging},
+		{" -InternalBattery-0 (id=1)	52%; discharging; 3:10 remaining present: true", Discharging},
+	}
+
+	for _, tt := range tests {
+		s, err := parse("Now drawing from 'AC Power'\n" + tt.line)
+		if err != nil || len(s.Sources) != 1 {
+			t.Fatalf("parse(%q) = %+v, %v", tt.line, s, err)
+		}
+		if got := s.Sources[0].State; got != tt.want {
+			t.Errorf("parse(%q).State = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseDesktop(t *testing.T) {
+	s, err := parse("Now drawing from 'AC Power'\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.OnAC() || s.InternalBattery() != nil || s.UPS() != nil {
+		t.Errorf("desktop status = %+v", s)
+	}
+
+	if _, err := parse("garbage"); err == nil {
+		t.Errorf("parse should fail without a 'Now drawing from' line")
+	}
+}
@@ -1,9 +1,8 @@
 package presets
 
 import (
-	"fmt"
-	"os/exec"
-	"strconv"
+	"github.com/born1337/macpwr/internal/plan"
+	"github.com/born1337/macpwr/internal/settings"
 )
 
 // Preset defines a power preset
@@ -12,6 +11,7 @@ type Preset struct {
 	Description string
 	AC          Settings
 	Battery     Settings
+	UPS         Settings
 }
 
 // Settings for a power source
@@ -19,6 +19,7 @@ type Settings struct {
 	DisplaySleep int
 	SystemSleep  int
 	DiskSleep    int
+	Extra        map[string]int // further pmset keys, such as lowpowermode or UPS halt thresholds
 }
 
 // All available presets
@@ -27,31 +28,40 @@ var All = []Preset{
 		Name:        "default",
 		Description: "Restore macOS default power settings",
 		AC:          Settings{DisplaySleep: 10, SystemSleep: 0, DiskSleep: 10},
-		Battery:     Settings{DisplaySleep: 2, SystemSleep: 10, DiskSleep: 10},
+		Battery: Settings{DisplaySleep: 2, SystemSleep: 10, DiskSleep: 10,
+			Extra: map[string]int{"lowpowermode": 0}},
+		UPS: Settings{DisplaySleep: 2, SystemSleep: 10, DiskSleep: 10},
 	},
 	{
 		Name:        "presentation",
 		Description: "Keep screen on, prevent sleep (ideal for presentations)",
 		AC:          Settings{DisplaySleep: 0, SystemSleep: 0, DiskSleep: 0},
 		Battery:     Settings{DisplaySleep: 0, SystemSleep: 0, DiskSleep: 0},
+		UPS:         Settings{DisplaySleep: 0, SystemSleep: 0, DiskSleep: 0},
 	},
 	{
 		Name:        "battery-saver",
 		Description: "Aggressive power saving to extend battery life",
 		AC:          Settings{DisplaySleep: 5, SystemSleep: 10, DiskSleep: 5},
-		Battery:     Settings{DisplaySleep: 1, SystemSleep: 2, DiskSleep: 2},
+		Battery: Settings{DisplaySleep: 1, SystemSleep: 2, DiskSleep: 2,
+			Extra: map[string]int{"lowpowermode": 1}},
+		UPS: Settings{DisplaySleep: 1, SystemSleep: 2, DiskSleep: 2,
+			Extra: map[string]int{"haltlevel": 20, "haltremain": 5}},
 	},
 	{
 		Name:        "performance",
 		Description: "Maximum performance, no sleep restrictions",
 		AC:          Settings{DisplaySleep: 0, SystemSleep: 0, DiskSleep: 0},
-		Battery:     Settings{DisplaySleep: 10, SystemSleep: 0, DiskSleep: 0},
+		Battery: Settings{DisplaySleep: 10, SystemSleep: 0, DiskSleep: 0,
+			Extra: map[string]int{"lowpowermode": 0}},
+		UPS: Settings{DisplaySleep: 10, SystemSleep: 0, DiskSleep: 0},
 	},
 	{
 		Name:        "movie",
 		Description: "Screen stays on, system can sleep normally",
 		AC:          Settings{DisplaySleep: 0, SystemSleep: 0, DiskSleep: 10},
 		Battery:     Settings{DisplaySleep: 0, SystemSleep: 30, DiskSleep: 10},
+		UPS:         Settings{DisplaySleep: 0, SystemSleep: 30, DiskSleep: 10},
 	},
 }
 
@@ -70,25 +80,27 @@ func Get(name string) *Preset {
 	return nil
 }
 
-// Apply applies a preset
-func Apply(p *Preset) error {
-	// Apply AC settings
-	if err := applySettings("-c", p.AC); err != nil {
-		return fmt.Errorf("failed to apply AC settings: %w", err)
+// For returns the preset's settings for a power source
+func (p *Preset) For(src settings.Source) Settings {
+	switch src {
+	case settings.Battery:
+		return p.Battery
+	case settings.UPS:
+		return p.UPS
 	}
-
-	// Apply battery settings
-	if err := applySettings("-b", p.Battery); err != nil {
-		return fmt.Errorf("failed to apply battery settings: %w", err)
-	}
-
-	return nil
+	return p.AC
 }
 
-func applySettings(powerSource string, s Settings) error {
-	cmd := exec.Command("sudo", "pmset", powerSource,
-		"displaysleep", strconv.Itoa(s.DisplaySleep),
-		"sleep", strconv.Itoa(s.SystemSleep),
-		"disksleep", strconv.Itoa(s.DiskSleep))
-	return cmd.Run()
+// Plan returns the pmset invocations that apply the preset to every power
+// source present in current
+func (p *Preset) Plan(current *settings.AllSettings) *plan.Plan {
+	pl := plan.New(current)
+	for _, src := range current.Available() {
+		s := p.For(src)
+		pl.Set(src, "displaysleep", s.DisplaySleep)
+		pl.Set(src, "sleep", s.SystemSleep)
+		pl.Set(src, "disksleep", s.DiskSleep)
+		pl.SetSupported(src, s.Extra)
+	}
+	return pl
 }
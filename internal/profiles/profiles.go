@@ -4,13 +4,13 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/born1337/macpwr/internal/plan"
 	"github.com/born1337/macpwr/internal/settings"
 )
 
@@ -18,16 +18,12 @@ import (
 type Profile struct {
 	Name    string
 	Created time.Time
-	Battery Settings
-	AC      Settings
+	Sources map[settings.Source]Settings
 }
 
-// Settings for a power source
-type Settings struct {
-	DisplaySleep int
-	SystemSleep  int
-	DiskSleep    int
-}
+// Settings maps the pmset keys a profile section sets to their values.
+// Keys the section leaves out are not part of the profile.
+type Settings map[string]int
 
 // ProfilesDir returns the profiles directory path
 func ProfilesDir() string {
@@ -90,30 +86,45 @@ func Save(name string) error {
 	defer f.Close()
 
 	fmt.Fprintf(f, "# macpwr profile: %s\n", name)
-	fmt.Fprintf(f, "# Created: %s\n\n", time.Now().Format(time.RFC1123))
-	fmt.Fprintln(f, "[battery]")
-	fmt.Fprintf(f, "displaysleep=%d\n", current.Battery.DisplaySleep)
-	fmt.Fprintf(f, "sleep=%d\n", current.Battery.SystemSleep)
-	fmt.Fprintf(f, "disksleep=%d\n\n", current.Battery.DiskSleep)
-	fmt.Fprintln(f, "[ac]")
-	fmt.Fprintf(f, "displaysleep=%d\n", current.AC.DisplaySleep)
-	fmt.Fprintf(f, "sleep=%d\n", current.AC.SystemSleep)
-	fmt.Fprintf(f, "disksleep=%d\n", current.AC.DiskSleep)
+	fmt.Fprintf(f, "# Created: %s\n", time.Now().Format(time.RFC1123))
+	for _, src := range current.Available() {
+		s := current.For(src)
+		fmt.Fprintf(f, "\n[%s]\n", sectionName(src))
+		fmt.Fprintf(f, "displaysleep=%d\n", s.DisplaySleep)
+		fmt.Fprintf(f, "sleep=%d\n", s.SystemSleep)
+		fmt.Fprintf(f, "disksleep=%d\n", s.DiskSleep)
+		for _, k := range settings.KeysFor(src) {
+			if val, ok := s.Value(k.Name); ok && s.Has(k.Name) {
+				fmt.Fprintf(f, "%s=%d\n", k.Name, val)
+			}
+		}
+	}
 
 	return nil
 }
 
+// sectionName returns the profile file section name for a power source
+func sectionName(src settings.Source) string {
+	return strings.ToLower(string(src))
+}
+
 // Load loads a profile from disk
 func Load(name string) (*Profile, error) {
-	path := filepath.Join(ProfilesDir(), name+".profile")
+	return LoadFile(filepath.Join(ProfilesDir(), name+".profile"))
+}
+
+// LoadFile loads a profile from an arbitrary path, such as a snapshot saved
+// outside the profiles directory
+func LoadFile(path string) (*Profile, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	p := &Profile{Name: name}
-	var currentSection string
+	name := strings.TrimSuffix(filepath.Base(path), ".profile")
+	p := &Profile{Name: name, Sources: make(map[settings.Source]Settings)}
+	var currentSection settings.Source
 
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
@@ -121,62 +132,47 @@ func Load(name string) (*Profile, error) {
 
 		if strings.HasPrefix(line, "# Created:") {
 			p.Created, _ = time.Parse(time.RFC1123, strings.TrimPrefix(line, "# Created: "))
-		} else if line == "[battery]" {
-			currentSection = "battery"
-		} else if line == "[ac]" {
-			currentSection = "ac"
-		} else if strings.Contains(line, "=") {
+		} else if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			src, err := settings.ParseSource(strings.Trim(line, "[]"))
+			if err != nil {
+				return nil, fmt.Errorf("profile %s: %w", name, err)
+			}
+			currentSection = src
+			p.Sources[src] = Settings{}
+		} else if strings.Contains(line, "=") && currentSection != "" {
 			parts := strings.SplitN(line, "=", 2)
 			key := parts[0]
-			val, _ := strconv.Atoi(parts[1])
-
-			switch currentSection {
-			case "battery":
-				switch key {
-				case "displaysleep":
-					p.Battery.DisplaySleep = val
-				case "sleep":
-					p.Battery.SystemSleep = val
-				case "disksleep":
-					p.Battery.DiskSleep = val
-				}
-			case "ac":
-				switch key {
-				case "displaysleep":
-					p.AC.DisplaySleep = val
-				case "sleep":
-					p.AC.SystemSleep = val
-				case "disksleep":
-					p.AC.DiskSleep = val
-				}
+			val, err := strconv.Atoi(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("profile %s: invalid value for %s: %q", name, key, parts[1])
+			}
+			if err := settings.ValidateValue(key, val); err != nil {
+				return nil, fmt.Errorf("profile %s: %w", name, err)
+			}
+			if err := settings.ValidateSource(key, currentSection); err != nil {
+				return nil, fmt.Errorf("profile %s: [%s] %w", name, sectionName(currentSection), err)
 			}
+
+			p.Sources[currentSection][key] = val
 		}
 	}
 
 	return p, scanner.Err()
 }
 
-// Apply applies a profile's settings
-func Apply(p *Profile) error {
-	// Apply battery settings
-	cmd := exec.Command("sudo", "pmset", "-b",
-		"displaysleep", strconv.Itoa(p.Battery.DisplaySleep),
-		"sleep", strconv.Itoa(p.Battery.SystemSleep),
-		"disksleep", strconv.Itoa(p.Battery.DiskSleep))
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to apply battery settings: %w", err)
-	}
-
-	// Apply AC settings
-	cmd = exec.Command("sudo", "pmset", "-c",
-		"displaysleep", strconv.Itoa(p.AC.DisplaySleep),
-		"sleep", strconv.Itoa(p.AC.SystemSleep),
-		"disksleep", strconv.Itoa(p.AC.DiskSleep))
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to apply AC settings: %w", err)
+// Plan returns the pmset invocations that apply the keys the profile sets
+// to every power source it has settings for and that is present in current.
+// Keys this Mac does not support are skipped, as for presets.
+func (p *Profile) Plan(current *settings.AllSettings) *plan.Plan {
+	pl := plan.New(current)
+	for _, src := range current.Available() {
+		s, ok := p.Sources[src]
+		if !ok {
+			continue
+		}
+		pl.SetSupported(src, s)
 	}
-
-	return nil
+	return pl
 }
 
 // Delete deletes a profile
@@ -0,0 +1,116 @@
+package profiles
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/born1337/macpwr/internal/runner"
+	"github.com/born1337/macpwr/internal/settings"
+)
+
+const customOutput = `AC Power:
+ displaysleep         10
+ sleep                0
+ disksleep            10
+UPS Power:
+ haltlevel            20
+ haltafter            0
+ haltremain           5
+ displaysleep         2
+ sleep                10
+ disksleep            10
+`
+
+// capOutput lists the keys this Mac supports; it has no Low Power Mode
+const capOutput = `Capabilities for AC Power:
+ displaysleep
+ sleep
+ disksleep
+Capabilities for UPS Power:
+ haltlevel
+ haltafter
+ haltremain
+ displaysleep
+ sleep
+ disksleep
+`
+
+// currentSettings reads customOutput and capOutput as the current settings
+func currentSettings(t *testing.T) *settings.AllSettings {
+	t.Helper()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, runner.FixtureName("pmset", "-g", "custom")), []byte(customOutput), 0644)
+	os.WriteFile(filepath.Join(dir, runner.FixtureName("pmset", "-g", "cap")), []byte(capOutput), 0644)
+	orig := runner.Default
+	runner.Default = runner.NewReplay(dir)
+	defer func() { runner.Default = orig }()
+
+	current, err := settings.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return current
+}
+
+func TestLoadFileSparseSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ups.profile")
+	os.WriteFile(path, []byte("# macpwr profile: ups\n\n[ac]\ndisplaysleep=15\n\n[ups]\nhaltlevel=30\n"), 0644)
+
+	p, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[settings.Source]Settings{
+		settings.AC:  {"displaysleep": 15},
+		settings.UPS: {"haltlevel": 30},
+	}
+	if !reflect.DeepEqual(p.Sources, want) {
+		t.Errorf("Sources = %v, want %v", p.Sources, want)
+	}
+
+	// Only the keys the profile sets are planned
+	var changed []string
+	for _, c := range p.Plan(currentSettings(t)).Changes() {
+		if c.Changed() {
+			changed = append(changed, string(c.Source)+" "+c.Key)
+		}
+	}
+	wantChanged := []string{"AC displaysleep", "UPS haltlevel"}
+	if !reflect.DeepEqual(changed, wantChanged) {
+		t.Errorf("changed = %q, want %q", changed, wantChanged)
+	}
+}
+
+func TestPlanSkipsUnsupportedKeys(t *testing.T) {
+	p := &Profile{Sources: map[settings.Source]Settings{
+		settings.AC: {"displaysleep": 10, "lowpowermode": 1},
+	}}
+
+	pl := p.Plan(currentSettings(t))
+	if err := pl.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want unsupported keys skipped", err)
+	}
+	for _, c := range pl.Changes() {
+		if c.Key == "lowpowermode" {
+			t.Errorf("plan sets lowpowermode on a Mac without it")
+		}
+	}
+}
+
+func TestLoadFileRejectsBadValues(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"value":  "[ups]\nhaltlevel=high\n",
+		"range":  "[ups]\nhaltlevel=150\n",
+		"source": "[battery]\nhaltlevel=30\n",
+	} {
+		path := filepath.Join(dir, name+".profile")
+		os.WriteFile(path, []byte(content), 0644)
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile(%s) accepted %q", name, content)
+		}
+	}
+}
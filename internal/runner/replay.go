@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FixtureName returns the fixture file name for a command line, e.g.
+// "pmset -g custom" becomes "pmset_-g_custom.txt"
+func FixtureName(name string, args ...string) string {
+	line := strings.Join(append([]string{name}, args...), " ")
+	var b strings.Builder
+	for _, r := range line {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String() + ".txt"
+}
+
+// Replay serves captured command outputs from a fixture directory. Commands
+// that would change the system are recorded in Calls instead of executed.
+type Replay struct {
+	Dir string
+
+	mu    sync.Mutex
+	Calls []string
+}
+
+// NewReplay returns a Replay reading fixtures from dir
+func NewReplay(dir string) *Replay {
+	return &Replay{Dir: dir}
+}
+
+// Output returns the captured output for a command. A missing fixture is
+// reported as a command failure, as if the tool were unavailable.
+func (r *Replay) Output(name string, args ...string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(r.Dir, FixtureName(name, args...)))
+	if err != nil {
+		return nil, fmt.Errorf("replay: no fixture for %q", strings.Join(append([]string{name}, args...), " "))
+	}
+	return data, nil
+}
+
+// Run records the command without executing it
+func (r *Replay) Run(name string, args ...string) error {
+	r.record(name, args)
+	return nil
+}
+
+// Start records the command and returns a process that exits when ctx is done
+func (r *Replay) Start(ctx context.Context, name string, args ...string) (Process, error) {
+	r.record(name, args)
+	return replayProcess{ctx}, nil
+}
+
+func (r *Replay) record(name string, args []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Calls = append(r.Calls, strings.Join(append([]string{name}, args...), " "))
+}
+
+type replayProcess struct {
+	ctx context.Context
+}
+
+func (p replayProcess) Wait() error {
+	<-p.ctx.Done()
+	return nil
+}
+
+// Recorder runs commands for real and saves their outputs as fixtures
+type Recorder struct {
+	Dir string
+	Exec
+}
+
+// NewRecorder returns a Recorder writing fixtures to dir
+func NewRecorder(dir string) *Recorder {
+	return &Recorder{Dir: dir}
+}
+
+// Output runs a command and captures its output to the fixture directory
+func (r *Recorder) Output(name string, args ...string) ([]byte, error) {
+	out, err := r.Exec.Output(name, args...)
+	if err != nil {
+		return out, err
+	}
+	if mkErr := os.MkdirAll(r.Dir, 0755); mkErr == nil {
+		os.WriteFile(filepath.Join(r.Dir, FixtureName(name, args...)), out, 0644)
+	}
+	return out, nil
+}
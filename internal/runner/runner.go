@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+// Runner executes external commands such as pmset, ioreg and sysctl
+type Runner interface {
+	// Output runs a command and returns its standard output
+	Output(name string, args ...string) ([]byte, error)
+
+	// Run runs a command attached to the terminal (used for sudo prompts)
+	Run(name string, args ...string) error
+
+	// Start starts a long-running command that is killed when ctx is done
+	Start(ctx context.Context, name string, args ...string) (Process, error)
+}
+
+// Process is a command started by Runner.Start
+type Process interface {
+	Wait() error
+}
+
+// Default is the Runner used by all macpwr packages
+var Default Runner = Exec{}
+
+// FromEnv returns the Runner selected by the environment:
+//
+//	MACPWR_FIXTURES=dir  replay captured outputs from dir
+//	MACPWR_RECORD=dir    run commands for real and capture outputs to dir
+func FromEnv() Runner {
+	if dir := os.Getenv("MACPWR_FIXTURES"); dir != "" {
+		return NewReplay(dir)
+	}
+	if dir := os.Getenv("MACPWR_RECORD"); dir != "" {
+		return NewRecorder(dir)
+	}
+	return Exec{}
+}
+
+// Exec runs commands on the local system
+type Exec struct{}
+
+// Output runs a command and returns its standard output
+func (Exec) Output(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// Run runs a command attached to the terminal
+func (Exec) Run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Start starts a long-running command that is killed when ctx is done
+func (Exec) Start(ctx context.Context, name string, args ...string) (Process, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
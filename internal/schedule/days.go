@@ -0,0 +1,115 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Days is a set of weekdays for a repeating event, Monday in the lowest bit
+// as pmset stores it
+type Days uint8
+
+const (
+	Monday Days = 1 << iota
+	Tuesday
+	Wednesday
+	Thursday
+	Friday
+	Saturday
+	Sunday
+
+	Weekdays = Monday | Tuesday | Wednesday | Thursday | Friday
+	Weekends = Saturday | Sunday
+	EveryDay = Weekdays | Weekends
+)
+
+// dayLetters are pmset's weekday letters, in bit order
+const dayLetters = "MTWRFSU"
+
+var dayNames = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+// ParseDays parses a weekday set. It accepts pmset letters ("MTWRF"), day
+// names or abbreviations ("mon,wed,fri"), "weekdays", "weekends" and
+// "every day", including pmset's own "weekdays only" wording.
+func ParseDays(s string) (Days, error) {
+	s = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), " only")
+	switch s {
+	case "every day", "everyday", "daily":
+		return EveryDay, nil
+	case "weekdays":
+		return Weekdays, nil
+	case "weekends":
+		return Weekends, nil
+	case "":
+		return 0, fmt.Errorf("no weekdays given")
+	}
+
+	if d, ok := parseLetters(s); ok {
+		return d, nil
+	}
+
+	var days Days
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		d, ok := parseDayName(f)
+		if !ok {
+			return 0, fmt.Errorf("invalid weekday %q (use letters MTWRFSU, day names, weekdays, weekends or daily)", f)
+		}
+		days |= d
+	}
+	return days, nil
+}
+
+func parseLetters(s string) (Days, bool) {
+	var days Days
+	for _, r := range strings.ToUpper(s) {
+		i := strings.IndexRune(dayLetters, r)
+		if i < 0 || days&(1<<i) != 0 {
+			return 0, false
+		}
+		days |= 1 << i
+	}
+	return days, true
+}
+
+func parseDayName(s string) (Days, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	for i, name := range dayNames {
+		if strings.HasPrefix(name, s) {
+			return 1 << i, true
+		}
+	}
+	return 0, false
+}
+
+// Letters returns the days as pmset weekday letters, such as "MTWRF"
+func (d Days) Letters() string {
+	var b strings.Builder
+	for i := range dayLetters {
+		if d&(1<<i) != 0 {
+			b.WriteByte(dayLetters[i])
+		}
+	}
+	return b.String()
+}
+
+// String describes the days, such as "weekdays" or "Mon Wed Fri"
+func (d Days) String() string {
+	switch d {
+	case EveryDay:
+		return "every day"
+	case Weekdays:
+		return "weekdays"
+	case Weekends:
+		return "weekends"
+	}
+
+	var names []string
+	for i, name := range dayNames {
+		if d&(1<<i) != 0 {
+			names = append(names, strings.ToUpper(name[:1])+name[1:3])
+		}
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,225 @@
+package schedule
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/born1337/macpwr/internal/runner"
+)
+
+// EventType is a pmset power event type
+type EventType string
+
+const (
+	Wake          EventType = "wake"
+	Sleep         EventType = "sleep"
+	PowerOn       EventType = "poweron"
+	Shutdown      EventType = "shutdown"
+	WakeOrPowerOn EventType = "wakeorpoweron"
+)
+
+// EventTypes lists the event types accepted by pmset schedule and repeat
+var EventTypes = []EventType{Wake, Sleep, PowerOn, Shutdown, WakeOrPowerOn}
+
+// ParseEventType parses an event type as given on the command line or as
+// reported by pmset -g sched ("wakepoweron")
+func ParseEventType(name string) (EventType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "wakepoweron" {
+		return WakeOrPowerOn, nil
+	}
+	for _, t := range EventTypes {
+		if name == string(t) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown event type %q (use wake, sleep, poweron, shutdown or wakeorpoweron)", name)
+}
+
+// Label returns a human-readable name for the event type
+func (t EventType) Label() string {
+	switch t {
+	case Wake:
+		return "Wake"
+	case Sleep:
+		return "Sleep"
+	case PowerOn:
+		return "Power On"
+	case Shutdown:
+		return "Shut Down"
+	case WakeOrPowerOn:
+		return "Wake or Power On"
+	}
+	return string(t)
+}
+
+// powersOn reports whether the event starts the machine. pmset keeps at most
+// one repeating event that powers on and one that powers off.
+func (t EventType) powersOn() bool {
+	return t == Wake || t == PowerOn || t == WakeOrPowerOn
+}
+
+// ScheduledEvent is a one-off or repeating power event
+type ScheduledEvent struct {
+	Type EventType
+	// Time is the date and time of a one-off event, or only the time of day
+	// of a repeating event
+	Time   time.Time
+	Owner  string
+	Repeat Days // zero for one-off events
+}
+
+// Repeating reports whether the event repeats on a weekly schedule
+func (e ScheduledEvent) Repeating() bool {
+	return e.Repeat != 0
+}
+
+// String describes the event on one line
+func (e ScheduledEvent) String() string {
+	if e.Repeating() {
+		return fmt.Sprintf("%s at %s %s", e.Type.Label(), e.Time.Format("15:04"), e.Repeat)
+	}
+	s := fmt.Sprintf("%s at %s", e.Type.Label(), e.Time.Format("2006-01-02 15:04"))
+	if e.Owner != "" {
+		s += " by " + e.Owner
+	}
+	return s
+}
+
+// Schedule contains the repeating and one-off events known to pmset
+type Schedule struct {
+	Repeating []ScheduledEvent
+	Events    []ScheduledEvent
+}
+
+// All returns the repeating events followed by the one-off events
+func (s *Schedule) All() []ScheduledEvent {
+	return append(append([]ScheduledEvent{}, s.Repeating...), s.Events...)
+}
+
+// Get retrieves the power event schedule
+func Get() (*Schedule, error) {
+	output, err := runner.Default.Output("pmset", "-g", "sched")
+	if err != nil {
+		return nil, err
+	}
+	return parseSched(string(output)), nil
+}
+
+var (
+	repeatLine = regexp.MustCompile(`^(\S+) at (\d{1,2}:\d{2}\s*[AP]M)\s+(.+)$`)
+	eventLine  = regexp.MustCompile(`^\[\d+\]\s+(\S+) at (\d{2}/\d{2}/\d{4} \d{2}:\d{2}:\d{2})(?: by '([^']*)')?`)
+)
+
+// parseSched parses the output of pmset -g sched
+func parseSched(data string) *Schedule {
+	s := &Schedule{}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+
+		if m := eventLine.FindStringSubmatch(line); m != nil {
+			at, err := time.ParseInLocation("01/02/2006 15:04:05", m[2], time.Local)
+			if err != nil {
+				continue
+			}
+			s.Events = append(s.Events, ScheduledEvent{Type: eventType(m[1]), Time: at, Owner: m[3]})
+			continue
+		}
+
+		if m := repeatLine.FindStringSubmatch(line); m != nil {
+			at, err := time.Parse("3:04PM", strings.ReplaceAll(m[2], " ", ""))
+			if err != nil {
+				continue
+			}
+			days, err := ParseDays(m[3])
+			if err != nil {
+				continue
+			}
+			s.Repeating = append(s.Repeating, ScheduledEvent{Type: eventType(m[1]), Time: at, Repeat: days})
+		}
+	}
+	return s
+}
+
+// eventType maps a type reported by pmset, keeping unknown types verbatim
+func eventType(name string) EventType {
+	if t, err := ParseEventType(name); err == nil {
+		return t
+	}
+	return EventType(name)
+}
+
+// ScheduleArgs returns the pmset arguments that schedule a one-off event
+func ScheduleArgs(e ScheduledEvent) []string {
+	args := []string{"schedule", string(e.Type), e.Time.Format("01/02/06 15:04:05")}
+	if e.Owner != "" {
+		args = append(args, e.Owner)
+	}
+	return args
+}
+
+// CancelArgs returns the pmset arguments that cancel a one-off event
+func CancelArgs(e ScheduledEvent) []string {
+	return append([]string{"schedule", "cancel"}, ScheduleArgs(e)[1:]...)
+}
+
+// RepeatArgs returns the pmset arguments that set the repeating schedule to
+// add combined with the existing repeating events. pmset replaces the whole
+// repeating schedule and keeps one power-on and one power-off event, so an
+// existing event of the same kind as add is replaced.
+func RepeatArgs(existing []ScheduledEvent, add ScheduledEvent) []string {
+	args := []string{"repeat"}
+	for _, e := range existing {
+		if e.Type.powersOn() != add.Type.powersOn() {
+			args = append(args, repeatArgs(e)...)
+		}
+	}
+	return append(args, repeatArgs(add)...)
+}
+
+func repeatArgs(e ScheduledEvent) []string {
+	return []string{string(e.Type), e.Repeat.Letters(), e.Time.Format("15:04:05")}
+}
+
+// ParseTime parses the time of a one-off event. It accepts
+// "2006-01-02 15:04[:05]", pmset's "01/02/06 15:04:05", or a time of day
+// alone, meaning its next occurrence after now. Times in the past are rejected.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{
+		"2006-01-02 15:04",
+		"2006-01-02 15:04:05",
+		"01/02/06 15:04:05",
+		"01/02/2006 15:04:05",
+	} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			if !t.After(now) {
+				return time.Time{}, fmt.Errorf("time %q is in the past", s)
+			}
+			return t, nil
+		}
+	}
+
+	tod, err := ParseTimeOfDay(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date/time %q (use YYYY-MM-DD HH:MM or HH:MM)", s)
+	}
+	t := time.Date(now.Year(), now.Month(), now.Day(), tod.Hour(), tod.Minute(), tod.Second(), 0, now.Location())
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// ParseTimeOfDay parses a time of day such as "07:30", "23:00:00" or "7:30PM"
+func ParseTimeOfDay(s string) (time.Time, error) {
+	s = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	for _, layout := range []string{"15:04", "15:04:05", "3:04PM", "3PM"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use HH:MM, HH:MM:SS or H:MMAM/PM)", s)
+}
@@ -0,0 +1,121 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSched(t *testing.T) {
+	data := `Repeating power events:
+  wakepoweron at 7:30AM weekdays only
+  sleep at 11:00PM Monday Wednesday Friday
+Scheduled power events:
+ [0]  wake at 10/17/2026 06:00:00 by 'com.apple.alarm.user-visible-Weekly Backup'
+ [1]  shutdown at 10/18/2026 23:30:00
+`
+	s := parseSched(data)
+
+	if len(s.Repeating) != 2 {
+		t.Fatalf("Repeating = %v, want 2 events", s.Repeating)
+	}
+	r := s.Repeating[0]
+	if r.Type != WakeOrPowerOn || r.Repeat != Weekdays || r.Time.Format("15:04") != "07:30" {
+		t.Errorf("Repeating[0] = %+v", r)
+	}
+	if got := s.Repeating[1].Repeat; got != Monday|Wednesday|Friday {
+		t.Errorf("Repeating[1].Repeat = %v", got)
+	}
+
+	if len(s.Events) != 2 {
+		t.Fatalf("Events = %v, want 2 events", s.Events)
+	}
+	e := s.Events[0]
+	if e.Type != Wake || e.Owner != "com.apple.alarm.user-visible-Weekly Backup" ||
+		e.Time.Format("2006-01-02 15:04:05") != "2026-10-17 06:00:00" {
+		t.Errorf("Events[0] = %+v", e)
+	}
+	if e := s.Events[1]; e.Type != Shutdown || e.Owner != "" {
+		t.Errorf("Events[1] = %+v", e)
+	}
+
+	if s := parseSched("No scheduled events.\n"); len(s.All()) != 0 {
+		t.Errorf("empty schedule parsed as %v", s.All())
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Days
+		letters string
+		wantErr bool
+	}{
+		{"MTWRF", Weekdays, "MTWRF", false},
+		{"su", Weekends, "SU", false},
+		{"weekdays only", Weekdays, "MTWRF", false},
+		{"daily", EveryDay, "MTWRFSU", false},
+		{"mon,wed,fri", Monday | Wednesday | Friday, "MWF", false},
+		{"Tuesday Thursday", Tuesday | Thursday, "TR", false},
+		{"MM", 0, "", true},
+		{"funday", 0, "", true},
+		{"", 0, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDays(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDays(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want || got.Letters() != tt.letters {
+			t.Errorf("ParseDays(%q) = %v (%s), want %v (%s)", tt.in, got, got.Letters(), tt.want, tt.letters)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"2026-10-20 07:00", "2026-10-20 07:00:00", false},
+		{"10/20/26 07:00:00", "2026-10-20 07:00:00", false},
+		{"18:30", "2026-10-16 18:30:00", false},
+		{"07:00", "2026-10-17 07:00:00", false},
+		{"2026-10-15 07:00", "", true},
+		{"2026-13-01 07:00", "", true},
+		{"25:00", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTime(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.Format("2006-01-02 15:04:05") != tt.want {
+			t.Errorf("ParseTime(%q) = %v, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRepeatArgs(t *testing.T) {
+	at := func(s string) time.Time {
+		tod, _ := ParseTimeOfDay(s)
+		return tod
+	}
+	existing := []ScheduledEvent{
+		{Type: WakeOrPowerOn, Time: at("7:30AM"), Repeat: Weekdays},
+		{Type: Sleep, Time: at("23:00"), Repeat: EveryDay},
+	}
+
+	got := RepeatArgs(existing, ScheduledEvent{Type: Shutdown, Time: at("22:00"), Repeat: Weekends})
+	want := []string{"repeat", "wakeorpoweron", "MTWRF", "07:30:00", "shutdown", "SU", "22:00:00"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RepeatArgs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,88 @@
+package settings
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/runner"
+)
+
+// Capabilities lists the settings each power source supports on this Mac,
+// as reported by pmset -g cap. A nil *Capabilities supports everything, so
+// callers work unchanged when the probe is unavailable.
+type Capabilities struct {
+	Model   string // hardware model, such as "Mac14,9"
+	Sources map[Source]map[string]bool
+}
+
+// GetCapabilities probes the settings supported by this Mac
+func GetCapabilities() (*Capabilities, error) {
+	output, err := runner.Default.Output("pmset", "-g", "cap")
+	if err != nil {
+		return nil, err
+	}
+
+	c := parseCapabilities(string(output))
+	if out, err := runner.Default.Output("sysctl", "-n", "hw.model"); err == nil {
+		c.Model = strings.TrimSpace(string(out))
+	}
+	return c, nil
+}
+
+// parseCapabilities parses the output of pmset -g cap, which lists the
+// supported keys under "Capabilities for AC Power:" and similar headers
+func parseCapabilities(data string) *Capabilities {
+	c := &Capabilities{Sources: make(map[Source]map[string]bool)}
+
+	var current Source
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if header, ok := strings.CutPrefix(line, "Capabilities for "); ok {
+			current = ""
+			if src, ok := sectionHeader(header); ok {
+				current = src
+				c.Sources[src] = make(map[string]bool)
+			}
+			continue
+		}
+		if current != "" && line != "" && !strings.Contains(line, " ") {
+			c.Sources[current][line] = true
+		}
+	}
+	return c
+}
+
+// Supports reports whether a power source supports a key. Sources the probe
+// did not report on are assumed to support every key.
+func (c *Capabilities) Supports(src Source, key string) bool {
+	if c == nil {
+		return true
+	}
+	keys, ok := c.Sources[src]
+	if !ok {
+		return true
+	}
+	return keys[key]
+}
+
+// SupportsAny reports whether any of the given power sources supports a key
+func (c *Capabilities) SupportsAny(sources []Source, key string) bool {
+	for _, src := range sources {
+		if c.Supports(src, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns an error naming the Mac if a power source does not support a key
+func (c *Capabilities) Check(src Source, key string) error {
+	if c.Supports(src, key) {
+		return nil
+	}
+	mac := "this Mac"
+	if c.Model != "" {
+		mac += " (" + c.Model + ")"
+	}
+	return fmt.Errorf("%s is not supported on %s power by %s", key, src, mac)
+}
@@ -0,0 +1,117 @@
+package settings
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/born1337/macpwr/internal/powersource"
+	"github.com/born1337/macpwr/internal/runner"
+)
+
+// Override explains why an effective value differs from the configured one
+type Override struct {
+	Reason    string   // e.g. "sleep prevented by coreaudiod, sharingd"
+	Processes []string // processes named in the reason
+}
+
+// Effective contains the settings currently in use, as reported by pmset -g
+type Effective struct {
+	Source        Source // power source the settings belong to, if known
+	Settings      *PowerSettings
+	Overrides     map[string]Override
+	SleepDisabled bool
+}
+
+// Overridden reports whether a key's effective value differs from the
+// configured settings, or pmset reports something preventing it
+func (e *Effective) Overridden(key string, configured *PowerSettings) bool {
+	if _, ok := e.Overrides[key]; ok {
+		return true
+	}
+	if configured == nil || !configured.Has(key) || !e.Settings.Has(key) {
+		return false
+	}
+	want, _ := configured.Value(key)
+	got, _ := e.Settings.Value(key)
+	return want != got
+}
+
+// GetEffective retrieves the settings currently in effect
+func GetEffective() (*Effective, error) {
+	output, err := runner.Default.Output("pmset", "-g")
+	if err != nil {
+		return nil, err
+	}
+
+	e := parseEffective(string(output))
+	e.Source, _ = ActiveSource()
+	return e, nil
+}
+
+var annotationRe = regexp.MustCompile(`\(([^)]*)\)`)
+
+// parseEffective parses the output of pmset -g
+func parseEffective(data string) *Effective {
+	e := &Effective{Overrides: make(map[string]Override)}
+
+	var inUse []string
+	inSection := false
+	for _, line := range strings.Split(data, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "Currently in use:":
+			inSection = true
+			continue
+		case strings.HasSuffix(trimmed, ":"):
+			inSection = false
+			continue
+		case strings.HasPrefix(trimmed, "SleepDisabled"):
+			e.SleepDisabled = strings.HasSuffix(trimmed, "1")
+			continue
+		}
+		if !inSection {
+			continue
+		}
+
+		// Strip annotations so the value parses, but remember them
+		if m := annotationRe.FindStringSubmatch(trimmed); m != nil {
+			if key, _, ok := parseLine(annotationRe.ReplaceAllString(trimmed, "")); ok {
+				e.Overrides[key] = parseOverride(m[1])
+			}
+			trimmed = annotationRe.ReplaceAllString(trimmed, "")
+		}
+		inUse = append(inUse, trimmed)
+	}
+
+	e.Settings = parseSection(strings.Join(inUse, "\n"))
+	return e
+}
+
+// parseOverride parses annotations such as
+// "sleep prevented by coreaudiod, sharingd"
+func parseOverride(reason string) Override {
+	o := Override{Reason: strings.TrimSpace(reason)}
+	if idx := strings.Index(reason, " by "); idx != -1 {
+		for _, p := range strings.Split(reason[idx+len(" by "):], ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				o.Processes = append(o.Processes, p)
+			}
+		}
+	}
+	return o
+}
+
+// ActiveSource returns the power source the Mac is currently drawing from
+func ActiveSource() (Source, bool) {
+	st, err := powersource.Get()
+	if err != nil {
+		return "", false
+	}
+
+	for _, src := range Sources {
+		if st.Drawing == string(src)+" Power" {
+			return src, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,105 @@
+package settings
+
+// Kind describes how a pmset value should be interpreted
+type Kind int
+
+const (
+	Minutes Kind = iota // 0 = never
+	Seconds
+	Bool
+	Percent
+	Enum
+)
+
+// Key describes a pmset setting known to macpwr
+type Key struct {
+	Name     string // pmset key name
+	Label    string // human-readable label
+	Category string
+	Kind     Kind
+	Choices  map[int]string // names for Enum values
+	Max      int            // upper bound for Minutes and Seconds (0 = none)
+	Only     Source         // the one power source the key applies to ("" = all)
+}
+
+// Categories lists setting categories in display order
+var Categories = []string{
+	"Sleep",
+	"Wake",
+	"Hibernation & Standby",
+	"Power",
+	"Network",
+	"UPS",
+}
+
+// Keys lists every pmset setting macpwr models, grouped by category
+var Keys = []Key{
+	{Name: "displaysleep", Label: "Display Sleep", Category: "Sleep", Kind: Minutes, Max: 180},
+	{Name: "sleep", Label: "System Sleep", Category: "Sleep", Kind: Minutes, Max: 1440},
+	{Name: "disksleep", Label: "Disk Sleep", Category: "Sleep", Kind: Minutes, Max: 1440},
+	{Name: "halfdim", Label: "Dim Before Sleep", Category: "Sleep", Kind: Bool},
+	{Name: "lessbright", Label: "Dim on Battery", Category: "Sleep", Kind: Bool},
+
+	{Name: "womp", Label: "Wake on LAN", Category: "Wake", Kind: Bool},
+	{Name: "ring", Label: "Wake on Modem Ring", Category: "Wake", Kind: Bool},
+	{Name: "lidwake", Label: "Wake on Lid Open", Category: "Wake", Kind: Bool},
+	{Name: "acwake", Label: "Wake on Power Change", Category: "Wake", Kind: Bool},
+	{Name: "proximitywake", Label: "Proximity Wake", Category: "Wake", Kind: Bool},
+	{Name: "ttyskeepawake", Label: "TTY Keeps Awake", Category: "Wake", Kind: Bool},
+	{Name: "powernap", Label: "Power Nap", Category: "Wake", Kind: Bool},
+
+	{Name: "hibernatemode", Label: "Hibernate Mode", Category: "Hibernation & Standby", Kind: Enum,
+		Choices: map[int]string{0: "0 (RAM)", 3: "3 (Safe)", 25: "25 (Disk)"}},
+	{Name: "standby", Label: "Standby", Category: "Hibernation & Standby", Kind: Bool},
+	{Name: "standbydelaylow", Label: "Standby Delay (Low)", Category: "Hibernation & Standby", Kind: Seconds, Max: 604800},
+	{Name: "standbydelayhigh", Label: "Standby Delay (High)", Category: "Hibernation & Standby", Kind: Seconds, Max: 604800},
+	{Name: "highstandbythreshold", Label: "High Standby Threshold", Category: "Hibernation & Standby", Kind: Percent},
+	{Name: "autopoweroff", Label: "Auto Power Off", Category: "Hibernation & Standby", Kind: Bool},
+	{Name: "autopoweroffdelay", Label: "Auto Power Off Delay", Category: "Hibernation & Standby", Kind: Seconds, Max: 604800},
+
+	{Name: "lowpowermode", Label: "Low Power Mode", Category: "Power", Kind: Bool},
+	{Name: "powermode", Label: "Energy Mode", Category: "Power", Kind: Enum,
+		Choices: map[int]string{0: "Automatic", 1: "Low Power", 2: "High Power"}},
+	{Name: "gpuswitch", Label: "GPU Switching", Category: "Power", Kind: Enum,
+		Choices: map[int]string{0: "Integrated", 1: "Discrete", 2: "Automatic"}},
+	{Name: "autorestart", Label: "Restart on Power Loss", Category: "Power", Kind: Bool},
+
+	{Name: "tcpkeepalive", Label: "TCP Keep Alive", Category: "Network", Kind: Bool},
+	{Name: "networkoversleep", Label: "Network Over Sleep", Category: "Network", Kind: Bool},
+
+	{Name: "haltlevel", Label: "Halt at Battery Level", Category: "UPS", Kind: Percent, Only: UPS},
+	{Name: "haltafter", Label: "Halt After", Category: "UPS", Kind: Minutes, Max: 1440, Only: UPS},
+	{Name: "haltremain", Label: "Halt at Time Remaining", Category: "UPS", Kind: Minutes, Max: 1440, Only: UPS},
+}
+
+// LookupKey returns the Key for a pmset key name
+func LookupKey(name string) (Key, bool) {
+	for _, k := range Keys {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// KeysIn returns the keys belonging to a category
+func KeysIn(category string) []Key {
+	var keys []Key
+	for _, k := range Keys {
+		if k.Category == category {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// KeysFor returns the keys that only apply to one power source
+func KeysFor(src Source) []Key {
+	var keys []Key
+	for _, k := range Keys {
+		if k.Only == src {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
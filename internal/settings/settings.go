@@ -2,126 +2,275 @@ package settings
 
 import (
 	"fmt"
-	"os/exec"
-	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/born1337/macpwr/internal/runner"
 )
 
 // PowerSettings contains power settings for a power source
 type PowerSettings struct {
-	DisplaySleep  int
-	SystemSleep   int
-	DiskSleep     int
-	PowerNap      bool
+	// Sleep
+	DisplaySleep int
+	SystemSleep  int
+	DiskSleep    int
+	HalfDim      bool
+	LessBright   bool
+
+	// Wake
 	WakeOnLAN     bool
-	LowPowerMode  bool
-	TCPKeepAlive  bool
+	WakeOnRing    bool
+	LidWake       bool
+	ACWake        bool
+	ProximityWake bool
+	TTYSKeepAwake bool
+	PowerNap      bool
+
+	// Hibernation & standby
+	HibernateMode        int
+	HibernateFile        string
+	Standby              bool
+	StandbyDelayLow      int // seconds
+	StandbyDelayHigh     int // seconds
+	HighStandbyThreshold int // percent
+	AutoPowerOff         bool
+	AutoPowerOffDelay    int // seconds
+
+	// Power
+	LowPowerMode bool
+	PowerMode    int
+	GPUSwitch    int
+	AutoRestart  bool
+
+	// Network
+	TCPKeepAlive     bool
+	NetworkOverSleep bool
+
+	// UPS (0 = disabled)
+	HaltLevel  int // percent
+	HaltAfter  int // minutes
+	HaltRemain int // minutes
+
+	// Other holds keys macpwr does not model, with their raw values
+	Other map[string]string
+
+	present map[string]bool
 }
 
-// AllSettings contains settings for both power sources
-type AllSettings struct {
-	Battery *PowerSettings
-	AC      *PowerSettings
+// Has reports whether pmset reported the key for this power source
+func (p *PowerSettings) Has(key string) bool {
+	return p.present[key]
 }
 
-// Get retrieves current power settings
-func Get() (*AllSettings, error) {
-	cmd := exec.Command("pmset", "-g", "custom")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
+// Value returns a modelled setting as an integer (booleans are 0 or 1)
+func (p *PowerSettings) Value(key string) (int, bool) {
+	ip, bp := p.field(key)
+	switch {
+	case ip != nil:
+		return *ip, true
+	case bp != nil:
+		if *bp {
+			return 1, true
+		}
+		return 0, true
 	}
+	return 0, false
+}
 
-	data := string(output)
-
-	// Split into battery and AC sections
-	parts := strings.Split(data, "AC Power:")
-	if len(parts) < 2 {
-		return nil, fmt.Errorf("unexpected pmset output format")
+func (p *PowerSettings) setValue(key string, val int) bool {
+	ip, bp := p.field(key)
+	switch {
+	case ip != nil:
+		*ip = val
+	case bp != nil:
+		*bp = val == 1
+	default:
+		return false
 	}
+	return true
+}
 
-	batterySection := parts[0]
-	acSection := parts[1]
+func (p *PowerSettings) field(key string) (*int, *bool) {
+	switch key {
+	case "displaysleep":
+		return &p.DisplaySleep, nil
+	case "sleep":
+		return &p.SystemSleep, nil
+	case "disksleep":
+		return &p.DiskSleep, nil
+	case "halfdim":
+		return nil, &p.HalfDim
+	case "lessbright":
+		return nil, &p.LessBright
+	case "womp":
+		return nil, &p.WakeOnLAN
+	case "ring":
+		return nil, &p.WakeOnRing
+	case "lidwake":
+		return nil, &p.LidWake
+	case "acwake":
+		return nil, &p.ACWake
+	case "proximitywake":
+		return nil, &p.ProximityWake
+	case "ttyskeepawake":
+		return nil, &p.TTYSKeepAwake
+	case "powernap":
+		return nil, &p.PowerNap
+	case "hibernatemode":
+		return &p.HibernateMode, nil
+	case "standby":
+		return nil, &p.Standby
+	case "standbydelaylow":
+		return &p.StandbyDelayLow, nil
+	case "standbydelayhigh":
+		return &p.StandbyDelayHigh, nil
+	case "highstandbythreshold":
+		return &p.HighStandbyThreshold, nil
+	case "autopoweroff":
+		return nil, &p.AutoPowerOff
+	case "autopoweroffdelay":
+		return &p.AutoPowerOffDelay, nil
+	case "lowpowermode":
+		return nil, &p.LowPowerMode
+	case "powermode":
+		return &p.PowerMode, nil
+	case "gpuswitch":
+		return &p.GPUSwitch, nil
+	case "autorestart":
+		return nil, &p.AutoRestart
+	case "tcpkeepalive":
+		return nil, &p.TCPKeepAlive
+	case "networkoversleep":
+		return nil, &p.NetworkOverSleep
+	case "haltlevel":
+		return &p.HaltLevel, nil
+	case "haltafter":
+		return &p.HaltAfter, nil
+	case "haltremain":
+		return &p.HaltRemain, nil
+	}
+	return nil, nil
+}
 
-	return &AllSettings{
-		Battery: parseSection(batterySection),
-		AC:      parseSection(acSection),
-	}, nil
+// AllSettings contains settings for every power source pmset reports
+type AllSettings struct {
+	Sources map[Source]*PowerSettings
+	Caps    *Capabilities // nil if the capabilities probe failed
 }
 
-func parseSection(section string) *PowerSettings {
-	return &PowerSettings{
-		DisplaySleep:  parseIntSetting(section, "displaysleep"),
-		SystemSleep:   parseIntSetting(section, "sleep"),
-		DiskSleep:     parseIntSetting(section, "disksleep"),
-		PowerNap:      parseBoolSetting(section, "powernap"),
-		WakeOnLAN:     parseBoolSetting(section, "womp"),
-		LowPowerMode:  parseBoolSetting(section, "lowpowermode"),
-		TCPKeepAlive:  parseBoolSetting(section, "tcpkeepalive"),
-	}
+// For returns the settings for a power source, or nil if it is not present
+func (a *AllSettings) For(src Source) *PowerSettings {
+	return a.Sources[src]
 }
 
-func parseIntSetting(section, key string) int {
-	re := regexp.MustCompile(`(?m)^\s*` + key + `\s+(\d+)`)
-	matches := re.FindStringSubmatch(section)
-	if len(matches) >= 2 {
-		val, _ := strconv.Atoi(matches[1])
-		return val
+// Available returns the power sources present, in display order
+func (a *AllSettings) Available() []Source {
+	var sources []Source
+	for _, src := range Sources {
+		if _, ok := a.Sources[src]; ok {
+			sources = append(sources, src)
+		}
 	}
-	return 0
+	return sources
 }
 
-func parseBoolSetting(section, key string) bool {
-	re := regexp.MustCompile(`(?m)^\s*` + key + `\s+(\d+)`)
-	matches := re.FindStringSubmatch(section)
-	if len(matches) >= 2 {
-		return matches[1] == "1"
+// Get retrieves current power settings and the capabilities of this Mac
+func Get() (*AllSettings, error) {
+	output, err := runner.Default.Output("pmset", "-g", "custom")
+	if err != nil {
+		return nil, err
 	}
-	return false
-}
 
-// SetOptions contains options for setting power settings
-type SetOptions struct {
-	PowerSource  string // "-c" for AC, "-b" for battery
-	DisplaySleep *int
-	SystemSleep  *int
-	DiskSleep    *int
+	all, err := parseCustom(string(output))
+	if err != nil {
+		return nil, err
+	}
+	all.Caps, _ = GetCapabilities()
+	return all, nil
 }
 
-// Set applies power settings
-func Set(opts SetOptions) error {
-	args := []string{opts.PowerSource}
+// parseCustom parses the output of pmset -g custom, which contains one
+// section per power source ("Battery Power:", "AC Power:", "UPS Power:")
+func parseCustom(data string) (*AllSettings, error) {
+	all := &AllSettings{Sources: make(map[Source]*PowerSettings)}
 
-	if opts.DisplaySleep != nil {
-		args = append(args, "displaysleep", strconv.Itoa(*opts.DisplaySleep))
+	var current Source
+	var section []string
+	flush := func() {
+		if current != "" {
+			all.Sources[current] = parseSection(strings.Join(section, "\n"))
+		}
+		section = nil
 	}
-	if opts.SystemSleep != nil {
-		args = append(args, "sleep", strconv.Itoa(*opts.SystemSleep))
+
+	for _, line := range strings.Split(data, "\n") {
+		if src, ok := sectionHeader(line); ok {
+			flush()
+			current = src
+			continue
+		}
+		section = append(section, line)
 	}
-	if opts.DiskSleep != nil {
-		args = append(args, "disksleep", strconv.Itoa(*opts.DiskSleep))
+	flush()
+
+	if len(all.Sources) == 0 {
+		return nil, fmt.Errorf("unexpected pmset output format")
 	}
+	return all, nil
+}
 
-	cmd := exec.Command("sudo", append([]string{"pmset"}, args...)...)
-	cmd.Stdin = nil
-	return cmd.Run()
+func sectionHeader(line string) (Source, bool) {
+	line = strings.TrimSpace(line)
+	for _, src := range Sources {
+		if line == src.header() {
+			return src, true
+		}
+	}
+	return "", false
 }
 
-// GetPowerSource returns the current power source
-func GetPowerSource() string {
-	cmd := exec.Command("pmset", "-g", "batt")
-	output, err := cmd.Output()
-	if err != nil {
-		return "Unknown"
+func parseSection(section string) *PowerSettings {
+	p := &PowerSettings{
+		Other:   make(map[string]string),
+		present: make(map[string]bool),
 	}
 
-	data := string(output)
-	if strings.Contains(data, "AC Power") {
-		return "AC Power"
+	for _, line := range strings.Split(section, "\n") {
+		key, val, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+
+		if key == "hibernatefile" {
+			p.HibernateFile = val
+			p.present[key] = true
+			continue
+		}
+
+		n, err := strconv.Atoi(val)
+		if err != nil || !p.setValue(key, n) {
+			p.Other[key] = val
+		}
+		p.present[key] = true
 	}
-	if strings.Contains(data, "Battery") {
-		return "Battery"
+
+	return p
+}
+
+// parseLine splits a pmset line such as " displaysleep  10" into key and value.
+// Keys may contain spaces ("Sleep On Power Button 1"), so the key runs up to
+// the first numeric field; lines without a numeric field use the first word.
+func parseLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasSuffix(line, ":") {
+		return "", "", false
+	}
+
+	for i := 1; i < len(fields); i++ {
+		if _, err := strconv.Atoi(fields[i]); err == nil {
+			return strings.Join(fields[:i], " "), fields[i], true
+		}
 	}
-	return "Unknown"
+	return fields[0], strings.Join(fields[1:], " "), true
 }
@@ -0,0 +1,311 @@
+package settings
+
+import "testing"
+
+const customOutput = `Battery Power:
+ lidwake              1
+ lowpowermode         0
+ standby              1
+ ttyskeepawake        1
+ hibernatemode        3
+ powernap             0
+ hibernatefile        /var/vm/sleepimage
+ displaysleep         2
+ womp                 0
+ networkoversleep     0
+ sleep                1
+ lessbright           1
+ tcpkeepalive         1
+ disksleep            10
+ Sleep On Power Button 1
+AC Power:
+ lidwake              1
+ lowpowermode         0
+ standby              1
+ ttyskeepawake        1
+ hibernatemode        3
+ powernap             1
+ hibernatefile        /var/vm/sleepimage
+ displaysleep         10
+ womp                 1
+ networkoversleep     0
+ sleep                1
+ powermode            2
+ tcpkeepalive         1
+ disksleep            10
+ futurekey            7
+`
+
+func TestParseCustom(t *testing.T) {
+	all, err := parseCustom(customOutput)
+	if err != nil {
+		t.Fatalf("parseCustom: %v", err)
+	}
+	p := all.For(Battery)
+
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"displaysleep", 2},
+		{"sleep", 1},
+		{"disksleep", 10},
+		{"hibernatemode", 3},
+		{"standby", 1},
+		{"lidwake", 1},
+		{"lessbright", 1},
+		{"powernap", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, ok := p.Value(tt.key)
+			if !ok {
+				t.Fatalf("Value(%q) not modelled", tt.key)
+			}
+			if got != tt.want {
+				t.Errorf("Value(%q) = %d, want %d", tt.key, got, tt.want)
+			}
+		})
+	}
+
+	if p.HibernateFile != "/var/vm/sleepimage" {
+		t.Errorf("HibernateFile = %q, want /var/vm/sleepimage", p.HibernateFile)
+	}
+	if p.Has("proximitywake") {
+		t.Errorf("Has(proximitywake) = true for a key pmset did not report")
+	}
+	if got := p.Other["Sleep On Power Button"]; got != "1" {
+		t.Errorf("Other[Sleep On Power Button] = %q, want 1", got)
+	}
+	if _, ok := p.Other["Battery"]; ok {
+		t.Errorf("section header parsed as a setting")
+	}
+
+	if all.For(AC).PowerMode != 2 || !all.For(AC).PowerNap || all.For(AC).DisplaySleep != 10 {
+		t.Errorf("AC section parsed incorrectly: %+v", all.For(AC))
+	}
+	if got := all.For(AC).Other["futurekey"]; got != "7" {
+		t.Errorf("AC Other[futurekey] = %q, want 7", got)
+	}
+}
+
+func TestParseCustomSources(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Source
+	}{
+		{"laptop", customOutput, []Source{Battery, AC}},
+		{"desktop", "AC Power:\n sleep 0\n", []Source{AC}},
+		{"desktop with UPS", "UPS Power:\n sleep 10\n haltlevel 20\nAC Power:\n sleep 0\n", []Source{AC, UPS}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			all, err := parseCustom(tt.data)
+			if err != nil {
+				t.Fatalf("parseCustom: %v", err)
+			}
+			got := all.Available()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Available() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Available() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	all, _ := parseCustom("UPS Power:\n sleep 10\nAC Power:\n sleep 0\n")
+	if all.For(UPS).SystemSleep != 10 || all.For(AC).SystemSleep != 0 {
+		t.Errorf("UPS and AC sections mixed up: UPS=%d AC=%d", all.For(UPS).SystemSleep, all.For(AC).SystemSleep)
+	}
+	if all.For(Battery) != nil {
+		t.Errorf("For(Battery) should be nil when pmset reports no battery section")
+	}
+
+	if _, err := parseCustom("garbage"); err == nil {
+		t.Errorf("parseCustom should fail without any power source section")
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		key    string
+		val    string
+		wantOK bool
+	}{
+		{" displaysleep         10", "displaysleep", "10", true},
+		{" hibernatefile        /var/vm/sleepimage", "hibernatefile", "/var/vm/sleepimage", true},
+		{" Sleep On Power Button 1", "Sleep On Power Button", "1", true},
+		{"AC Power:", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			key, val, ok := parseLine(tt.line)
+			if ok != tt.wantOK || key != tt.key || val != tt.val {
+				t.Errorf("parseLine(%q) = %q, %q, %v; want %q, %q, %v",
+					tt.line, key, val, ok, tt.key, tt.val, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	tests := []struct {
+		key     string
+		val     int
+		wantErr bool
+	}{
+		{"displaysleep", 10, false},
+		{"displaysleep", -1, true},
+		{"displaysleep", 500, true},
+		{"sleep", 0, false},
+		{"powernap", 1, false},
+		{"powernap", 2, true},
+		{"hibernatemode", 25, false},
+		{"hibernatemode", 1, true},
+		{"highstandbythreshold", 101, true},
+		{"nosuchkey", 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := ValidateValue(tt.key, tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateValue(%q, %d) = %v, wantErr %v", tt.key, tt.val, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCombination(t *testing.T) {
+	changed := map[string]bool{"displaysleep": true}
+
+	tests := []struct {
+		name    string
+		values  map[string]int
+		changed map[string]bool
+		want    int
+	}{
+		{"display before sleep", map[string]int{"displaysleep": 5, "sleep": 10}, changed, 0},
+		{"display after sleep", map[string]int{"displaysleep": 15, "sleep": 10}, changed, 1},
+		{"sleep never", map[string]int{"displaysleep": 15, "sleep": 0}, changed, 0},
+		{"untouched keys", map[string]int{"displaysleep": 15, "sleep": 10}, map[string]bool{"disksleep": true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateCombination(tt.values, tt.changed)
+			if len(got) != tt.want {
+				t.Errorf("ValidateCombination(%v) = %q, want %d problems", tt.values, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEffective(t *testing.T) {
+	data := `System-wide power settings:
+ SleepDisabled		1
+Currently in use:
+ standby              1
+ sleep                0 (sleep prevented by coreaudiod, sharingd)
+ displaysleep         10 (display sleep prevented by zoom.us)
+ disksleep            10
+`
+	e := parseEffective(data)
+
+	if !e.SleepDisabled {
+		t.Errorf("SleepDisabled = false, want true")
+	}
+	if e.Settings.SystemSleep != 0 || e.Settings.DisplaySleep != 10 || !e.Settings.Standby {
+		t.Errorf("Settings parsed incorrectly: %+v", e.Settings)
+	}
+
+	o, ok := e.Overrides["sleep"]
+	if !ok {
+		t.Fatalf("no override recorded for sleep")
+	}
+	if o.Reason != "sleep prevented by coreaudiod, sharingd" {
+		t.Errorf("Reason = %q", o.Reason)
+	}
+	if len(o.Processes) != 2 || o.Processes[0] != "coreaudiod" || o.Processes[1] != "sharingd" {
+		t.Errorf("Processes = %q, want [coreaudiod sharingd]", o.Processes)
+	}
+
+	configured := &PowerSettings{present: map[string]bool{"disksleep": true}, DiskSleep: 5}
+	if !e.Overridden("disksleep", configured) {
+		t.Errorf("disksleep configured 5 but in effect 10 should count as overridden")
+	}
+	if e.Overridden("standby", configured) {
+		t.Errorf("standby has no override and no configured value")
+	}
+}
+
+func TestValidateSource(t *testing.T) {
+	tests := []struct {
+		key     string
+		src     Source
+		wantErr bool
+	}{
+		{"haltlevel", UPS, false},
+		{"haltlevel", Battery, true},
+		{"haltremain", AC, true},
+		{"displaysleep", Battery, false},
+		{"unknown", AC, false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateSource(tt.key, tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateSource(%q, %s) error = %v, wantErr %v", tt.key, tt.src, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	c := parseCapabilities(`Capabilities for Battery Power:
+ displaysleep
+ lessbright
+Capabilities for AC Power:
+ displaysleep
+ womp
+`)
+	c.Model = "Mac14,9"
+
+	tests := []struct {
+		src  Source
+		key  string
+		want bool
+	}{
+		{Battery, "displaysleep", true},
+		{Battery, "womp", false},
+		{AC, "womp", true},
+		{AC, "lessbright", false},
+		{UPS, "haltlevel", true}, // not reported, assumed supported
+	}
+	for _, tt := range tests {
+		if got := c.Supports(tt.src, tt.key); got != tt.want {
+			t.Errorf("Supports(%s, %q) = %v, want %v", tt.src, tt.key, got, tt.want)
+		}
+	}
+
+	if !c.SupportsAny([]Source{Battery, AC}, "womp") {
+		t.Errorf("SupportsAny(womp) = false, want true")
+	}
+	err := c.Check(Battery, "womp")
+	if err == nil || err.Error() != "womp is not supported on Battery power by this Mac (Mac14,9)" {
+		t.Errorf("Check(Battery, womp) = %v", err)
+	}
+
+	var none *Capabilities
+	if !none.Supports(Battery, "gpuswitch") {
+		t.Errorf("nil Capabilities should support every key")
+	}
+}
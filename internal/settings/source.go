@@ -0,0 +1,54 @@
+package settings
+
+import "fmt"
+
+// Source identifies a power source as reported by pmset
+type Source string
+
+const (
+	Battery Source = "Battery"
+	AC      Source = "AC"
+	UPS     Source = "UPS"
+)
+
+// Sources lists all power sources in display order
+var Sources = []Source{Battery, AC, UPS}
+
+// Flag returns the pmset flag that targets this power source
+func (s Source) Flag() string {
+	switch s {
+	case Battery:
+		return "-b"
+	case AC:
+		return "-c"
+	case UPS:
+		return "-u"
+	}
+	return "-a"
+}
+
+// Label returns a human-readable name for the power source
+func (s Source) Label() string {
+	if s == Battery {
+		return "Battery"
+	}
+	return string(s) + " Power"
+}
+
+// header returns the section header used by pmset -g custom
+func (s Source) header() string {
+	return string(s) + " Power:"
+}
+
+// ParseSource converts a name such as "battery", "ac" or "ups" to a Source
+func ParseSource(name string) (Source, error) {
+	switch name {
+	case "battery", "bat", "b":
+		return Battery, nil
+	case "ac", "c":
+		return AC, nil
+	case "ups", "u":
+		return UPS, nil
+	}
+	return "", fmt.Errorf("unknown power source: %s (use battery, ac or ups)", name)
+}
@@ -0,0 +1,90 @@
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationError lists every problem found in a set of values
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid settings: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateValue checks a single value against the allowed range of its key
+func ValidateValue(key string, val int) error {
+	k, ok := LookupKey(key)
+	if !ok {
+		return fmt.Errorf("%s is not a setting macpwr can change", key)
+	}
+
+	switch k.Kind {
+	case Bool:
+		if val != 0 && val != 1 {
+			return fmt.Errorf("%s must be 0 or 1, got %d", key, val)
+		}
+	case Percent:
+		if val < 0 || val > 100 {
+			return fmt.Errorf("%s must be between 0 and 100, got %d", key, val)
+		}
+	case Enum:
+		if _, ok := k.Choices[val]; !ok {
+			return fmt.Errorf("%s must be one of %s, got %d", key, choiceList(k), val)
+		}
+	default:
+		if val < 0 {
+			return fmt.Errorf("%s cannot be negative, got %d", key, val)
+		}
+		if k.Max > 0 && val > k.Max {
+			return fmt.Errorf("%s must be at most %d, got %d", key, k.Max, val)
+		}
+	}
+	return nil
+}
+
+// ValidateSource checks that a key can be set for a power source
+func ValidateSource(key string, src Source) error {
+	k, ok := LookupKey(key)
+	if !ok || k.Only == "" || k.Only == src {
+		return nil
+	}
+	return fmt.Errorf("%s only applies to %s", key, k.Only.Label())
+}
+
+func choiceList(k Key) string {
+	var vals []int
+	for v := range k.Choices {
+		vals = append(vals, v)
+	}
+	sort.Ints(vals)
+
+	var names []string
+	for _, v := range vals {
+		names = append(names, strconv.Itoa(v))
+	}
+	return strings.Join(names, ", ")
+}
+
+// ValidateCombination checks rules that span several keys of one power
+// source. values holds the settings the source will have once applied; a
+// rule is only checked when one of its keys is in changed, so settings that
+// are already in place are never reported.
+func ValidateCombination(values map[string]int, changed map[string]bool) []string {
+	var problems []string
+
+	display, okDisplay := values["displaysleep"]
+	sleep, okSleep := values["sleep"]
+	if (changed["displaysleep"] || changed["sleep"]) &&
+		okDisplay && okSleep && sleep != 0 && display > sleep {
+		problems = append(problems, fmt.Sprintf(
+			"display sleep (%d min) must not exceed system sleep (%d min) unless system sleep is 0",
+			display, sleep))
+	}
+
+	return problems
+}
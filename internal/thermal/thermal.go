@@ -1,11 +1,13 @@
 package thermal
 
 import (
-	"os/exec"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+
+	"github.com/born1337/macpwr/internal/powersource"
+	"github.com/born1337/macpwr/internal/runner"
 )
 
 // Info contains thermal and CPU information
@@ -14,9 +16,9 @@ type Info struct {
 	CPUCores      int
 	Architecture  string
 	LoadAverage   string
-	MemoryFree    int // percentage
-	PowerSource   string
-	CPULimit      int // percentage (100 = no throttling)
+	MemoryFree    int                 // percentage
+	Power         *powersource.Status // nil if pmset cannot be read
+	CPULimit      int                 // percentage (100 = no throttling)
 	FansAvailable bool
 }
 
@@ -25,32 +27,35 @@ func Get() (*Info, error) {
 	info := &Info{}
 
 	// CPU Model
-	if out, err := exec.Command("sysctl", "-n", "machdep.cpu.brand_string").Output(); err == nil {
+	if out, err := runner.Default.Output("sysctl", "-n", "machdep.cpu.brand_string"); err == nil {
 		info.CPUModel = strings.TrimSpace(string(out))
 	}
 
 	// CPU Cores
-	if out, err := exec.Command("sysctl", "-n", "hw.ncpu").Output(); err == nil {
+	if out, err := runner.Default.Output("sysctl", "-n", "hw.ncpu"); err == nil {
 		info.CPUCores, _ = strconv.Atoi(strings.TrimSpace(string(out)))
 	}
 
-	// Architecture
-	info.Architecture = runtime.GOARCH
-	if info.Architecture == "arm64" {
-		info.Architecture = "Apple Silicon"
-	} else if info.Architecture == "amd64" {
-		info.Architecture = "Intel"
+	// Architecture (hw.optional.arm64 is also set under Rosetta)
+	if out, err := runner.Default.Output("sysctl", "-n", "hw.optional.arm64"); err == nil {
+		if strings.TrimSpace(string(out)) == "1" {
+			info.Architecture = "Apple Silicon"
+		} else {
+			info.Architecture = "Intel"
+		}
+	} else {
+		info.Architecture = runtime.GOARCH
 	}
 
 	// Load Average
-	if out, err := exec.Command("sysctl", "-n", "vm.loadavg").Output(); err == nil {
+	if out, err := runner.Default.Output("sysctl", "-n", "vm.loadavg"); err == nil {
 		load := strings.TrimSpace(string(out))
 		load = strings.Trim(load, "{}")
 		info.LoadAverage = strings.TrimSpace(load)
 	}
 
 	// Memory pressure
-	if out, err := exec.Command("memory_pressure").Output(); err == nil {
+	if out, err := runner.Default.Output("memory_pressure"); err == nil {
 		re := regexp.MustCompile(`System-wide memory free percentage:\s*(\d+)`)
 		matches := re.FindStringSubmatch(string(out))
 		if len(matches) >= 2 {
@@ -59,19 +64,12 @@ func Get() (*Info, error) {
 	}
 
 	// Power source
-	if out, err := exec.Command("pmset", "-g", "batt").Output(); err == nil {
-		data := string(out)
-		if strings.Contains(data, "AC Power") {
-			info.PowerSource = "AC Power"
-		} else if strings.Contains(data, "Battery") {
-			info.PowerSource = "Battery"
-		} else {
-			info.PowerSource = "Unknown"
-		}
+	if st, err := powersource.Get(); err == nil {
+		info.Power = st
 	}
 
 	// CPU thermal limit
-	if out, err := exec.Command("pmset", "-g", "therm").Output(); err == nil {
+	if out, err := runner.Default.Output("pmset", "-g", "therm"); err == nil {
 		re := regexp.MustCompile(`CPU_Scheduler_Limit\s*=\s*(\d+)`)
 		matches := re.FindStringSubmatch(string(out))
 		if len(matches) >= 2 {
@@ -80,11 +78,11 @@ func Get() (*Info, error) {
 	}
 
 	// Check for fans
-	if out, err := exec.Command("ioreg", "-rc", "AppleSMCACPIPlatformPlugin").Output(); err == nil {
+	if out, err := runner.Default.Output("ioreg", "-rc", "AppleSMCACPIPlatformPlugin"); err == nil {
 		info.FansAvailable = strings.Contains(strings.ToLower(string(out)), "fan")
 	}
 	if !info.FansAvailable {
-		if out, err := exec.Command("ioreg", "-rc", "AppleFanCtrl").Output(); err == nil {
+		if out, err := runner.Default.Output("ioreg", "-rc", "AppleFanCtrl"); err == nil {
 			info.FansAvailable = len(out) > 0
 		}
 	}